@@ -0,0 +1,121 @@
+package playwright
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// AccessibilitySnapshotOptions are the options of AccessibilitySnapshot
+type AccessibilitySnapshotOptions struct {
+	// InterestingOnly drops the generic containers without a name from the tree, defaults to true
+	InterestingOnly *bool `json:"interestingOnly"`
+}
+
+// uninterestingRoles are the roles of the nodes that only group other nodes and are collapsed when InterestingOnly is set
+var uninterestingRoles = map[string]bool{
+	"generic":       true,
+	"none":          true,
+	"presentation":  true,
+	"InlineTextBox": true,
+}
+
+// axNode is a node of the Accessibility.getFullAXTree devtools protocol result
+type axNode struct {
+	NodeID   string   `json:"nodeId"`
+	ParentID string   `json:"parentId"`
+	Ignored  bool     `json:"ignored"`
+	Role     *axValue `json:"role"`
+	Name     *axValue `json:"name"`
+	Value    *axValue `json:"value"`
+	ChildIDs []string `json:"childIds"`
+	Props    []struct {
+		Name  string   `json:"name"`
+		Value *axValue `json:"value"`
+	} `json:"properties"`
+}
+
+// axValue is a value of an accessibility node property
+type axValue struct {
+	Value interface{} `json:"value"`
+}
+
+// AccessibilitySnapshot returns the accessibility tree of the current page as nested objects with a role, a name, their states and their children,
+// or null if the page has no tree yet. The tree is read through the devtools protocol so it is only supported in chromium.
+func (p *Playwright) AccessibilitySnapshot(opts AccessibilitySnapshotOptions) (interface{}, error) {
+	session, err := p.cdpSession()
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with creating the devtools session")
+		return nil, err
+	}
+	defer session.Detach()
+	result, err := session.Send("Accessibility.getFullAXTree", map[string]interface{}{})
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with taking the accessibility snapshot")
+		return nil, err
+	}
+	var tree struct {
+		Nodes []axNode `json:"nodes"`
+	}
+	data, err := json.Marshal(result)
+	if err == nil {
+		err = json.Unmarshal(data, &tree)
+	}
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with reading the accessibility snapshot")
+		return nil, err
+	}
+	interestingOnly := opts.InterestingOnly == nil || *opts.InterestingOnly
+	nodes := make(map[string]*axNode, len(tree.Nodes))
+	for i := range tree.Nodes {
+		nodes[tree.Nodes[i].NodeID] = &tree.Nodes[i]
+	}
+	for i := range tree.Nodes {
+		if tree.Nodes[i].ParentID == "" {
+			snapshot := buildAXTree(&tree.Nodes[i], nodes, interestingOnly)
+			if len(snapshot) == 0 {
+				return nil, nil
+			}
+			return snapshot[0], nil
+		}
+	}
+	return nil, nil
+}
+
+// buildAXTree converts the node and its descendants to nested maps, an ignored or uninteresting node is replaced by its children
+func buildAXTree(node *axNode, nodes map[string]*axNode, interestingOnly bool) []map[string]interface{} {
+	var children []map[string]interface{}
+	for _, id := range node.ChildIDs {
+		if child, ok := nodes[id]; ok {
+			children = append(children, buildAXTree(child, nodes, interestingOnly)...)
+		}
+	}
+	role := axString(node.Role)
+	name := axString(node.Name)
+	if node.Ignored || role == "InlineTextBox" || (interestingOnly && uninterestingRoles[role] && name == "") {
+		return children
+	}
+	result := map[string]interface{}{
+		"role": role,
+		"name": name,
+	}
+	if node.Value != nil && node.Value.Value != nil {
+		result["value"] = node.Value.Value
+	}
+	for _, prop := range node.Props {
+		if prop.Value != nil && prop.Value.Value != nil {
+			result[prop.Name] = prop.Value.Value
+		}
+	}
+	if len(children) > 0 {
+		result["children"] = children
+	}
+	return []map[string]interface{}{result}
+}
+
+// axString returns the string value of the accessibility node property, or an empty string if it is not set
+func axString(value *axValue) string {
+	if value == nil || value.Value == nil {
+		return ""
+	}
+	return fmt.Sprint(value.Value)
+}
@@ -0,0 +1,160 @@
+package playwright
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// CDPSession is a handle around a chromium devtools protocol session attached to a page, for the browser features playwright does not wrap
+type CDPSession struct {
+	Self  playwright.CDPSession
+	owner *Playwright
+}
+
+// NewCDPSession opens a devtools protocol session attached to the current page, devtools sessions are only supported in chromium
+func (p *Playwright) NewCDPSession() (*CDPSession, error) {
+	session, err := p.cdpSession()
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with creating the devtools session")
+		return nil, err
+	}
+	return &CDPSession{Self: session, owner: p}, nil
+}
+
+// SetCPUThrottlingRate slows down the CPU of the current page by the given factor to simulate low-end devices, e.g. 4 for a 4x slower CPU,
+// 1 turning throttling off. It is only supported in chromium, as it goes through the devtools protocol.
+func (p *Playwright) SetCPUThrottlingRate(rate float64) error {
+	if rate < 1 {
+		err := fmt.Errorf("invalid cpu throttling rate %v, expected 1 (no throttling) or more", rate)
+		p.reportError(err, "xk6-playwright: invalid cpu throttling rate")
+		return err
+	}
+	if err := p.emulate(p.Page, "Emulation.setCPUThrottlingRate", map[string]interface{}{"rate": rate}); err != nil {
+		p.reportError(err, "xk6-playwright: error with throttling the cpu")
+		return err
+	}
+	return nil
+}
+
+// Send sends a devtools protocol command, e.g. "Network.emulateNetworkConditions", with its params and returns its result
+func (c *CDPSession) Send(method string, params map[string]interface{}) (map[string]interface{}, error) {
+	if params == nil {
+		params = map[string]interface{}{}
+	}
+	result, err := c.Self.Send(method, params)
+	if err != nil {
+		c.owner.reportError(err, "xk6-playwright: error with sending the devtools command")
+		return nil, err
+	}
+	if result == nil {
+		return map[string]interface{}{}, nil
+	}
+	values, ok := result.(map[string]interface{})
+	if !ok {
+		err := fmt.Errorf("unexpected result of %s: %v", method, result)
+		c.owner.reportError(err, "xk6-playwright: error with sending the devtools command")
+		return nil, err
+	}
+	return values, nil
+}
+
+// Detach wrapper around playwright detach session function that closes the session, it cannot send commands afterwards
+func (c *CDPSession) Detach() error {
+	if err := c.Self.Detach(); err != nil {
+		c.owner.reportError(err, "xk6-playwright: error with detaching the devtools session")
+		return err
+	}
+	return nil
+}
+
+// geolocationOverride is the geolocation SetGeolocation emulates on the pages of a chromium browser context
+type geolocationOverride struct {
+	mu     sync.Mutex
+	params map[string]interface{}
+}
+
+// set replaces the emulated geolocation
+func (g *geolocationOverride) set(params map[string]interface{}) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.params = params
+}
+
+// get returns the emulated geolocation
+func (g *geolocationOverride) get() map[string]interface{} {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.params
+}
+
+// geolocationOverride returns the geolocation override of the browser context, creating it on the first call along with the handler
+// applying it to the pages opened in the context afterwards
+func (p *Playwright) geolocationOverride(context playwright.BrowserContext) *geolocationOverride {
+	if override, ok := p.geolocations[context]; ok {
+		return override
+	}
+	override := &geolocationOverride{}
+	context.On("page", func(page playwright.Page) {
+		// the handler runs on the playwright dispatcher, which has to stay free to deliver the result of the devtools command
+		go func() {
+			params := override.get()
+			if params == nil {
+				return
+			}
+			if err := p.emulate(page, "Emulation.setGeolocationOverride", params); err != nil {
+				p.logError(err, "xk6-playwright: error with setting the geolocation of the new page")
+			}
+		}()
+	})
+	if p.geolocations == nil {
+		p.geolocations = make(map[playwright.BrowserContext]*geolocationOverride)
+	}
+	p.geolocations[context] = override
+	return override
+}
+
+// emulate sends an emulation command on the emulation session of the page
+func (p *Playwright) emulate(page playwright.Page, method string, params map[string]interface{}) error {
+	session, err := p.emulationSession(page)
+	if err != nil {
+		return err
+	}
+	_, err = session.Send(method, params)
+	return err
+}
+
+// emulationSession returns the devtools session of the page the emulation commands are sent on, the emulation lasts as long as the session
+// so it is kept open for the page and forgotten once the page closes, which ends the session with it
+func (p *Playwright) emulationSession(page playwright.Page) (playwright.CDPSession, error) {
+	if page == nil {
+		return nil, errors.New("no page attached")
+	}
+	p.mu.Lock()
+	session, ok := p.emulationSessions[page]
+	p.mu.Unlock()
+	if ok {
+		return session, nil
+	}
+	if p.engine != "chromium" {
+		return nil, fmt.Errorf("the devtools protocol is only supported in chromium, current browser is %q", p.engine)
+	}
+	session, err := page.Context().NewCDPSession(page)
+	if err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	if p.emulationSessions == nil {
+		p.emulationSessions = make(map[playwright.Page]playwright.CDPSession)
+	}
+	p.emulationSessions[page] = session
+	p.mu.Unlock()
+	page.On("close", func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		delete(p.emulationSessions, page)
+	})
+	return session, nil
+}
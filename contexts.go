@@ -0,0 +1,75 @@
+package playwright
+
+import (
+	"fmt"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// namedContext is a browser context created by CreateContext with the page that was current in it when another context was used
+type namedContext struct {
+	context playwright.BrowserContext
+	page    playwright.Page
+}
+
+// CreateContext creates a new browser context with the given options and a page within it, registered under the name, e.g. "admin" or "customer",
+// to model several users in a single VU. The current context is kept, UseContext switches to the new one.
+func (p *Playwright) CreateContext(name string, opts playwright.BrowserNewContextOptions) error {
+	if _, ok := p.namedContexts[name]; ok {
+		err := fmt.Errorf("a browser context named %q already exists", name)
+		p.reportError(err, "xk6-playwright: cannot create browser context")
+		return err
+	}
+	context, page, err := p.createContext(opts)
+	if err != nil {
+		p.reportError(err, "xk6-playwright: cannot create browser context")
+		return err
+	}
+	p.watchPage(page)
+	if p.namedContexts == nil {
+		p.namedContexts = make(map[string]*namedContext)
+	}
+	p.namedContexts[name] = &namedContext{context: context, page: page}
+	return nil
+}
+
+// UseContext makes the browser context created under the name by CreateContext, and the page last used in it, the current ones, so the
+// following page operations target it
+func (p *Playwright) UseContext(name string) error {
+	named, ok := p.namedContexts[name]
+	if !ok {
+		err := fmt.Errorf("no browser context named %q", name)
+		p.reportError(err, "xk6-playwright: cannot use browser context")
+		return err
+	}
+	for _, current := range p.namedContexts {
+		if current.context == p.BrowserContext && p.Page != nil {
+			current.page = p.Page
+		}
+	}
+	p.BrowserContext = named.context
+	p.Page = named.page
+	return nil
+}
+
+// CloseNamedContext closes the browser context created under the name by CreateContext and its pages, unlike CloseContext which closes the current
+// context. If it is the current context there is no current page afterwards until UseContext or NewContext is called.
+func (p *Playwright) CloseNamedContext(name string) error {
+	named, ok := p.namedContexts[name]
+	if !ok {
+		err := fmt.Errorf("no browser context named %q", name)
+		p.reportError(err, "xk6-playwright: cannot close browser context")
+		return err
+	}
+	if named.context == p.BrowserContext {
+		return p.CloseContext()
+	}
+	if err := named.context.Close(); err != nil {
+		p.reportError(err, "xk6-playwright: cannot close browser context")
+		return err
+	}
+	delete(p.namedContexts, name)
+	delete(p.routers, named.context)
+	delete(p.geolocations, named.context)
+	return nil
+}
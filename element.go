@@ -0,0 +1,228 @@
+package playwright
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// ElementHandle is a handle around a playwright element handle, its actions operate on that specific element without re-querying the page
+type ElementHandle struct {
+	Self  playwright.ElementHandle
+	owner *Playwright
+}
+
+// QuerySelector wrapper around playwright querySelector page function that returns a handle to the first element matching the selector, or null if none matches
+func (p *Playwright) QuerySelector(selector string) (*ElementHandle, error) {
+	element, err := p.Page.QuerySelector(selector)
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error querying selector")
+		return nil, err
+	}
+	if element == nil {
+		return nil, nil
+	}
+	p.trackHandle(element)
+	return &ElementHandle{Self: element, owner: p}, nil
+}
+
+// WaitForSelectorHandle wrapper around playwright waitForSelector page function that waits for the selector to reach the state option (attached, detached, visible or hidden)
+// and returns a handle to the matched element, or null when waiting for the element to be detached or hidden
+func (p *Playwright) WaitForSelectorHandle(selector string, opts playwright.PageWaitForSelectorOptions) (*ElementHandle, error) {
+	element, err := p.Page.WaitForSelector(selector, opts)
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error waiting for selector")
+		return nil, err
+	}
+	if element == nil {
+		return nil, nil
+	}
+	p.trackHandle(element)
+	return &ElementHandle{Self: element, owner: p}, nil
+}
+
+// BoundingBox returns the x, y, width and height in pixels of the first element matching the selector, failing if the element is not rendered
+func (p *Playwright) BoundingBox(selector string) (map[string]float64, error) {
+	element, err := p.Page.QuerySelector(selector)
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error querying selector")
+		return nil, err
+	}
+	if element == nil {
+		err := fmt.Errorf("no element matches selector %q", selector)
+		p.reportError(err, "xk6-playwright: error with getting the bounding box")
+		return nil, err
+	}
+	box, err := boundingBox(element)
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with getting the bounding box")
+		return nil, err
+	}
+	return box, nil
+}
+
+// ComputedStyle returns the computed value of the css property, e.g. "background-color", of the first element matching the selector,
+// failing if no element matches
+func (p *Playwright) ComputedStyle(selector string, property string) (string, error) {
+	element, err := p.Page.QuerySelector(selector)
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error querying selector")
+		return "", err
+	}
+	if element == nil {
+		err := fmt.Errorf("no element matches selector %q", selector)
+		p.reportError(err, "xk6-playwright: error with getting the computed style")
+		return "", err
+	}
+	value, err := element.Evaluate("(element, property) => getComputedStyle(element).getPropertyValue(property)", property)
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with getting the computed style")
+		return "", err
+	}
+	return fmt.Sprint(value), nil
+}
+
+// DOMHashOptions are the options of DOMHash
+type DOMHashOptions struct {
+	// IgnoreAttributes are the attributes, e.g. "id" or "data-timestamp", removed from the element and its descendants before hashing
+	IgnoreAttributes []string `js:"ignoreAttributes" json:"ignoreAttributes"`
+}
+
+// domSnapshotScript is the page function returning the outer html of a copy of the element without the ignored attributes, with the whitespace between tags removed
+// and any other whitespace run collapsed to a single space
+const domSnapshotScript = `(element, ignored) => {
+	const copy = element.cloneNode(true);
+	for (const node of [copy, ...copy.querySelectorAll('*')]) {
+		for (const name of ignored) node.removeAttribute(name);
+	}
+	return copy.outerHTML.replace(/>\s+</g, '><').replace(/\s+/g, ' ').trim();
+}`
+
+// DOMHash returns the sha256 hex digest of the normalized outer html of the first element matching the selector, which stays the same as long as
+// its markup does, so comparing it across iterations catches unexpected UI changes. It fails if no element matches.
+func (p *Playwright) DOMHash(selector string, opts DOMHashOptions) (string, error) {
+	element, err := p.Page.QuerySelector(selector)
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error querying selector")
+		return "", err
+	}
+	if element == nil {
+		err := fmt.Errorf("no element matches selector %q", selector)
+		p.reportError(err, "xk6-playwright: error with hashing the element")
+		return "", err
+	}
+	ignored := opts.IgnoreAttributes
+	if ignored == nil {
+		ignored = []string{}
+	}
+	html, err := element.Evaluate(domSnapshotScript, ignored)
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with hashing the element")
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprint(html)))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Click wrapper around playwright click element function that takes in a set of options
+func (e *ElementHandle) Click(opts playwright.ElementHandleClickOptions) error {
+	if err := e.Self.Click(opts); err != nil {
+		e.owner.reportError(err, "xk6-playwright: error with clicking the element")
+		return err
+	}
+	return nil
+}
+
+// TextContent wrapper around playwright textContent element function that returns the text content of the element
+func (e *ElementHandle) TextContent() (string, error) {
+	text, err := e.Self.TextContent()
+	if err != nil {
+		e.owner.reportError(err, "xk6-playwright: error with getting the text content of the element")
+		return "", err
+	}
+	return text, nil
+}
+
+// GetAttribute wrapper around playwright getAttribute element function that returns the value of the given attribute of the element
+func (e *ElementHandle) GetAttribute(name string) (string, error) {
+	value, err := e.Self.GetAttribute(name)
+	if err != nil {
+		e.owner.reportError(err, "xk6-playwright: error with getting the attribute of the element")
+		return "", err
+	}
+	return value, nil
+}
+
+// BoundingBox wrapper around playwright boundingBox element function that returns the x, y, width and height of the element in pixels
+func (e *ElementHandle) BoundingBox() (map[string]float64, error) {
+	box, err := boundingBox(e.Self)
+	if err != nil {
+		e.owner.reportError(err, "xk6-playwright: error with getting the bounding box of the element")
+		return nil, err
+	}
+	return box, nil
+}
+
+// Dispose wrapper around playwright dispose element function that releases the element in the browser, the handle cannot be used afterwards
+func (e *ElementHandle) Dispose() error {
+	e.owner.untrackHandle(e.Self)
+	if err := e.Self.Dispose(); err != nil {
+		e.owner.reportError(err, "xk6-playwright: error with disposing the element")
+		return err
+	}
+	return nil
+}
+
+// DisposeHandles releases in the browser every element and JS handle returned since the last call that was not disposed yet, which keeps long-running
+// VUs from accumulating them. The handles are released anyway when their browser context closes.
+func (p *Playwright) DisposeHandles() error {
+	var errs []string
+	for _, handle := range p.handles {
+		if err := handle.Dispose(); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	p.handles = nil
+	if len(errs) > 0 {
+		err := fmt.Errorf("cannot dispose %d handle(s): %s", len(errs), strings.Join(errs, "; "))
+		p.reportError(err, "xk6-playwright: error with disposing the handles")
+		return err
+	}
+	return nil
+}
+
+// trackHandle remembers the handle for DisposeHandles
+func (p *Playwright) trackHandle(handle playwright.JSHandle) {
+	p.handles = append(p.handles, handle)
+}
+
+// untrackHandle forgets a handle disposed on its own
+func (p *Playwright) untrackHandle(handle playwright.JSHandle) {
+	for i := range p.handles {
+		if p.handles[i] == handle {
+			p.handles = append(p.handles[:i], p.handles[i+1:]...)
+			return
+		}
+	}
+}
+
+// boundingBox returns the bounding box of the element, failing if the element is not rendered
+func boundingBox(element playwright.ElementHandle) (map[string]float64, error) {
+	rect, err := element.BoundingBox()
+	if err != nil {
+		return nil, err
+	}
+	if rect == nil {
+		return nil, errors.New("element is not rendered")
+	}
+	return map[string]float64{
+		"x":      float64(rect.X),
+		"y":      float64(rect.Y),
+		"width":  float64(rect.Width),
+		"height": float64(rect.Height),
+	}, nil
+}
@@ -0,0 +1,149 @@
+package playwright
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// defaultExpectTimeout is how long, in milliseconds, the expect assertions retry before failing
+const defaultExpectTimeout = 5000
+
+// expectPollInterval is how often the expect assertions re-check their condition
+const expectPollInterval = 100 * time.Millisecond
+
+// ExpectText asserts that the element matching the selector has the expected text, retrying until the assertion timeout expires
+func (p *Playwright) ExpectText(selector string, expected string) error {
+	locator, err := p.Page.Locator(selector)
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with creating the locator")
+		return err
+	}
+	var actual string
+	err = p.poll(p.expectTimeout(), func(remaining float64) (bool, error) {
+		text, err := locator.TextContent(playwright.FrameTextContentOptions{Timeout: playwright.Float(remaining)})
+		if err != nil {
+			return false, err
+		}
+		actual = strings.TrimSpace(text)
+		return actual == expected, nil
+	})
+	if err != nil {
+		err = fmt.Errorf("expected %q to have text %q, got %q: %w", selector, expected, actual, err)
+		p.reportError(err, "xk6-playwright: text assertion failed")
+		return err
+	}
+	return nil
+}
+
+// ExpectOptions are the options of the expect assertions taking options
+type ExpectOptions struct {
+	// Timeout is how long the assertion retries in milliseconds, defaults to the page default timeout or 5 seconds
+	Timeout *float64 `json:"timeout"`
+}
+
+// ExpectCount asserts that exactly the expected number of elements match the selector, retrying until the assertion timeout expires
+func (p *Playwright) ExpectCount(selector string, expected int, opts ExpectOptions) error {
+	locator, err := p.Page.Locator(selector)
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with creating the locator")
+		return err
+	}
+	timeout := p.expectTimeout()
+	if opts.Timeout != nil {
+		timeout = *opts.Timeout
+	}
+	var actual int
+	err = p.poll(timeout, func(float64) (bool, error) {
+		count, err := locator.Count()
+		if err != nil {
+			return false, err
+		}
+		actual = count
+		return actual == expected, nil
+	})
+	if err != nil {
+		err = fmt.Errorf("expected %q to match %d element(s), got %d: %w", selector, expected, actual, err)
+		p.reportError(err, "xk6-playwright: count assertion failed")
+		return err
+	}
+	return nil
+}
+
+// ExpectVisible asserts that the element matching the selector becomes visible before the assertion timeout expires
+func (p *Playwright) ExpectVisible(selector string) error {
+	locator, err := p.Page.Locator(selector)
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with creating the locator")
+		return err
+	}
+	opts := playwright.PageWaitForSelectorOptions{
+		State:   playwright.WaitForSelectorStateVisible,
+		Timeout: playwright.Float(p.expectTimeout()),
+	}
+	if err := locator.WaitFor(opts); err != nil {
+		err = fmt.Errorf("expected %q to be visible: %w", selector, err)
+		p.reportError(err, "xk6-playwright: visibility assertion failed")
+		return err
+	}
+	return nil
+}
+
+// ExpectURL asserts that the current page reaches a url matching the pattern, a glob pattern or a regular expression between slashes (see urlMatcher),
+// before the assertion timeout expires
+func (p *Playwright) ExpectURL(pattern string) error {
+	matches, err := urlMatcher(pattern)
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with compiling the url pattern")
+		return err
+	}
+	err = p.poll(p.expectTimeout(), func(float64) (bool, error) {
+		return matches(p.Page.URL()), nil
+	})
+	if err != nil {
+		err = fmt.Errorf("expected url to match %q, got %q: %w", pattern, p.Page.URL(), err)
+		p.reportError(err, "xk6-playwright: url assertion failed")
+		return err
+	}
+	return nil
+}
+
+// expectTimeout returns the assertion timeout, the page default timeout if one was set
+func (p *Playwright) expectTimeout() float64 {
+	if p.timeout != nil {
+		return *p.timeout
+	}
+	return defaultExpectTimeout
+}
+
+// poll calls check with the milliseconds left until the timeout expires, so checks that wait themselves stay within the deadline,
+// until it returns true or the timeout expires, returning the last error if any, and stops once the VU context is done
+func (p *Playwright) poll(timeout float64, check func(remaining float64) (bool, error)) error {
+	deadline := time.Now().Add(time.Duration(timeout * float64(time.Millisecond)))
+	for {
+		ok, err := check(remainingMillis(deadline))
+		if ok {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			if err != nil {
+				return err
+			}
+			return fmt.Errorf("timed out after %vms", timeout)
+		}
+		if err := p.sleep(expectPollInterval); err != nil {
+			return err
+		}
+	}
+}
+
+// remainingMillis returns the milliseconds left until the deadline, at least one as a timeout of 0 disables the playwright timeouts
+func remainingMillis(deadline time.Time) float64 {
+	remaining := float64(time.Until(deadline)) / float64(time.Millisecond)
+	if remaining < 1 {
+		return 1
+	}
+	return remaining
+}
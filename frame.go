@@ -0,0 +1,119 @@
+package playwright
+
+import (
+	"fmt"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// Frame is a handle around a playwright frame, its actions operate within the frame instead of the top-level page
+type Frame struct {
+	Self  playwright.Frame
+	owner *Playwright
+}
+
+// Frame returns the frame of the current page with the given name (the name or id attribute of the iframe)
+func (p *Playwright) Frame(name string) (*Frame, error) {
+	frame := p.Page.Frame(playwright.PageFrameOptions{Name: &name})
+	if frame == nil {
+		err := fmt.Errorf("no frame named %q", name)
+		p.reportError(err, "xk6-playwright: error with getting the frame")
+		return nil, err
+	}
+	return &Frame{Self: frame, owner: p}, nil
+}
+
+// FrameByURL returns the first frame of the current page whose url matches the given glob pattern
+func (p *Playwright) FrameByURL(urlPattern string) (*Frame, error) {
+	frame := p.Page.Frame(playwright.PageFrameOptions{URL: urlPattern})
+	if frame == nil {
+		err := fmt.Errorf("no frame matches url %q", urlPattern)
+		p.reportError(err, "xk6-playwright: error with getting the frame")
+		return nil, err
+	}
+	return &Frame{Self: frame, owner: p}, nil
+}
+
+// Frames returns the name and url of every frame of the current page, the main frame first, to find the values Frame and FrameByURL take
+func (p *Playwright) Frames() []map[string]string {
+	frames := p.Page.Frames()
+	result := make([]map[string]string, 0, len(frames))
+	for _, frame := range frames {
+		result = append(result, map[string]string{
+			"name": frame.Name(),
+			"url":  frame.URL(),
+		})
+	}
+	return result
+}
+
+// FrameLocator locates an iframe by selector and creates locators scoped to its content, the iframe is looked up again every time a locator acts,
+// so it keeps working when the iframe is reloaded or re-rendered
+type FrameLocator struct {
+	page     playwright.Page
+	selector string
+	owner    *Playwright
+}
+
+// FrameLocator returns a frame locator for the iframe matching the selector, e.g. "iframe[name='card']"
+func (p *Playwright) FrameLocator(selector string) *FrameLocator {
+	return &FrameLocator{page: p.Page, selector: selector, owner: p}
+}
+
+// Locator creates a locator for the elements matching the selector inside the iframe
+func (f *FrameLocator) Locator(selector string) (*Locator, error) {
+	// playwright-go has no frame locators yet, they are selectors entering the frame like the ones the other playwright clients build
+	locator, err := f.page.Locator(f.innerSelector(selector))
+	if err != nil {
+		f.owner.reportError(err, "xk6-playwright: error with creating the locator in the frame")
+		return nil, err
+	}
+	return &Locator{Self: locator, owner: f.owner}, nil
+}
+
+// FrameLocator returns a frame locator for the iframe matching the selector nested inside the iframe
+func (f *FrameLocator) FrameLocator(selector string) *FrameLocator {
+	return &FrameLocator{page: f.page, selector: f.innerSelector(selector), owner: f.owner}
+}
+
+// innerSelector returns a selector matching the given selector inside the iframe
+func (f *FrameLocator) innerSelector(selector string) string {
+	return f.selector + " >> control=enter-frame >> " + selector
+}
+
+// Click wrapper around playwright click frame function that takes in a selector and a set of options
+func (f *Frame) Click(selector string, opts playwright.PageClickOptions) error {
+	if err := f.Self.Click(selector, opts); err != nil {
+		f.owner.reportError(err, "xk6-playwright: error with clicking in the frame")
+		return err
+	}
+	return nil
+}
+
+// Fill wrapper around playwright fill frame function that takes in a selector, text, and a set of options
+func (f *Frame) Fill(selector string, filledString string, opts playwright.FrameFillOptions) error {
+	if err := f.Self.Fill(selector, filledString, opts); err != nil {
+		f.owner.reportError(err, "xk6-playwright: error with filling in the frame")
+		return err
+	}
+	return nil
+}
+
+// TextContent wrapper around playwright textContent frame function that returns the text content of the element matching the selector
+func (f *Frame) TextContent(selector string, opts playwright.FrameTextContentOptions) (string, error) {
+	text, err := f.Self.TextContent(selector, opts)
+	if err != nil {
+		f.owner.reportError(err, "xk6-playwright: error with getting the text content in the frame")
+		return "", err
+	}
+	return text, nil
+}
+
+// WaitForSelector wrapper around playwright waitForSelector frame function that takes in a selector and a set of options
+func (f *Frame) WaitForSelector(selector string, opts playwright.PageWaitForSelectorOptions) error {
+	if _, err := f.Self.WaitForSelector(selector, opts); err != nil {
+		f.owner.reportError(err, "xk6-playwright: error waiting for selector in the frame")
+		return err
+	}
+	return nil
+}
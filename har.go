@@ -0,0 +1,275 @@
+package playwright
+
+import (
+	"encoding/json"
+	"net/http"
+	neturl "net/url"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// harRecorder collects the responses received by a browser context and writes them as a HAR 1.2 file.
+// playwright-go does not expose the recordHar context options, so the log is built from the context's response events.
+type harRecorder struct {
+	mu        sync.Mutex
+	path      string
+	responses []playwright.Response
+}
+
+type harLog struct {
+	Log harLogContent `json:"log"`
+}
+
+type harLogContent struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+// modulePath is the path of the extension module, looked up in the build info for the HAR creator version
+const modulePath = "github.com/egor-romanov/xk6-playwright"
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	SSL     float64 `json:"ssl"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// newHarRecorder starts recording the responses of the given browser context into a HAR file at path
+func newHarRecorder(context playwright.BrowserContext, path string) *harRecorder {
+	recorder := &harRecorder{path: path}
+	context.On("response", func(response playwright.Response) {
+		recorder.mu.Lock()
+		defer recorder.mu.Unlock()
+		recorder.responses = append(recorder.responses, response)
+	})
+	return recorder
+}
+
+// write flushes the recorded entries to the HAR file, it should only be called once the context is closed so that all timings are final
+func (r *harRecorder) write() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entries := make([]harEntry, 0, len(r.responses))
+	for _, response := range r.responses {
+		entries = append(entries, newHarEntry(response))
+	}
+	har := harLog{
+		Log: harLogContent{
+			Version: "1.2",
+			Creator: harCreator{Name: "xk6-playwright", Version: moduleVersion()},
+			Entries: entries,
+		},
+	}
+	data, err := json.MarshalIndent(har, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFile(r.path, data, 0644)
+}
+
+// newHarEntry converts a response and its request into a HAR entry, using only data already available without a round-trip to the browser.
+// What playwright-go does not expose is marked as not available, -1 for the sizes and timings and "unknown" for the http version, rather than guessed.
+func newHarEntry(response playwright.Response) harEntry {
+	request := response.Request()
+	timing := request.Timing()
+	timings := harTimings{
+		Blocked: -1,
+		DNS:     harDuration(timing.DomainLookupStart, timing.DomainLookupEnd),
+		Connect: harDuration(timing.ConnectStart, timing.ConnectEnd),
+		SSL:     harDuration(timing.SecureConnectionStart, timing.ConnectEnd),
+		// playwright only marks the start of the request, so sending it is included in wait
+		Send:    -1,
+		Wait:    harDuration(timing.RequestStart, timing.ResponseStart),
+		Receive: harDuration(timing.ResponseStart, timing.ResponseEnd),
+	}
+	var total float64
+	for _, t := range []float64{timings.DNS, timings.Connect, timings.Wait, timings.Receive} {
+		if t > 0 {
+			total += t
+		}
+	}
+	redirectURL := ""
+	if redirectedTo := request.RedirectedTo(); redirectedTo != nil {
+		redirectURL = redirectedTo.URL()
+	}
+	requestHeaders := request.Headers()
+	requestBodySize := 0
+	if body, err := request.PostDataBuffer(); err != nil {
+		requestBodySize = -1
+	} else {
+		requestBodySize = len(body)
+	}
+	headers := response.Headers()
+	responseBodySize := -1
+	if size, err := strconv.Atoi(headers["content-length"]); err == nil {
+		responseBodySize = size
+	}
+	return harEntry{
+		StartedDateTime: time.UnixMilli(int64(timing.StartTime)).UTC().Format(time.RFC3339Nano),
+		Time:            total,
+		Request: harRequest{
+			Method:      request.Method(),
+			URL:         request.URL(),
+			HTTPVersion: "unknown",
+			Cookies:     harRequestCookies(requestHeaders["cookie"]),
+			Headers:     harHeaders(requestHeaders),
+			QueryString: harQueryString(request.URL()),
+			HeadersSize: -1,
+			BodySize:    requestBodySize,
+		},
+		Response: harResponse{
+			Status:      response.Status(),
+			StatusText:  response.StatusText(),
+			HTTPVersion: "unknown",
+			Cookies:     harResponseCookies(headers["set-cookie"]),
+			Headers:     harHeaders(headers),
+			Content:     harContent{Size: -1, MimeType: headers["content-type"]},
+			RedirectURL: redirectURL,
+			HeadersSize: -1,
+			BodySize:    responseBodySize,
+		},
+		Timings: timings,
+	}
+}
+
+// harQueryString returns the query parameters of the url as the HAR name/value list
+func harQueryString(rawURL string) []harNameValue {
+	values := []harNameValue{}
+	parsed, err := neturl.Parse(rawURL)
+	if err != nil {
+		return values
+	}
+	query := parsed.Query()
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range query[name] {
+			values = append(values, harNameValue{Name: name, Value: value})
+		}
+	}
+	return values
+}
+
+// harRequestCookies returns the cookies of the request cookie header as the HAR name/value list
+func harRequestCookies(header string) []harNameValue {
+	values := []harNameValue{}
+	if header == "" {
+		return values
+	}
+	request := http.Request{Header: http.Header{"Cookie": {header}}}
+	for _, cookie := range request.Cookies() {
+		values = append(values, harNameValue{Name: cookie.Name, Value: cookie.Value})
+	}
+	return values
+}
+
+// harResponseCookies returns the cookies of the response set-cookie header, whose values playwright joins with new lines, as the HAR name/value list
+func harResponseCookies(header string) []harNameValue {
+	values := []harNameValue{}
+	if header == "" {
+		return values
+	}
+	response := http.Response{Header: http.Header{"Set-Cookie": strings.Split(header, "\n")}}
+	for _, cookie := range response.Cookies() {
+		values = append(values, harNameValue{Name: cookie.Name, Value: cookie.Value})
+	}
+	return values
+}
+
+// moduleVersion returns the version of the extension module the binary was built with, or "unknown" when the build info does not tell
+func moduleVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	if info.Main.Path == modulePath && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == modulePath {
+			if dep.Replace != nil && dep.Replace.Version != "" {
+				return dep.Replace.Version
+			}
+			return dep.Version
+		}
+	}
+	return "unknown"
+}
+
+// harDuration returns the duration between two playwright timing marks, or -1 when one of them is not available
+func harDuration(start float64, end float64) float64 {
+	if start < 0 || end < 0 {
+		return -1
+	}
+	return end - start
+}
+
+// harHeaders converts a header map into the HAR name/value list
+func harHeaders(headers map[string]string) []harNameValue {
+	values := make([]harNameValue, 0, len(headers))
+	for name, value := range headers {
+		values = append(values, harNameValue{Name: name, Value: value})
+	}
+	return values
+}
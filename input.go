@@ -0,0 +1,121 @@
+package playwright
+
+import (
+	"fmt"
+
+	"github.com/playwright-community/playwright-go"
+	"github.com/tidwall/gjson"
+)
+
+//---------------------------------------------------------------------
+//                         KEYBOARD
+//---------------------------------------------------------------------
+
+// KeyboardPress wrapper around playwright keyboard press function that presses a key or a combination such as "Control+Shift+K"
+func (p *Playwright) KeyboardPress(key string, opts playwright.KeyboardPressOptions) error {
+	if err := p.Page.Keyboard().Press(key, opts); err != nil {
+		p.reportError(err, "xk6-playwright: error with pressing the key")
+		return err
+	}
+	return nil
+}
+
+// KeyboardDown wrapper around playwright keyboard down function that holds down a key
+func (p *Playwright) KeyboardDown(key string) error {
+	if err := p.Page.Keyboard().Down(key); err != nil {
+		p.reportError(err, "xk6-playwright: error with holding down the key")
+		return err
+	}
+	return nil
+}
+
+// KeyboardUp wrapper around playwright keyboard up function that releases a key
+func (p *Playwright) KeyboardUp(key string) error {
+	if err := p.Page.Keyboard().Up(key); err != nil {
+		p.reportError(err, "xk6-playwright: error with releasing the key")
+		return err
+	}
+	return nil
+}
+
+// KeyboardInsertText wrapper around playwright keyboard insertText function that inserts text without emitting key events
+func (p *Playwright) KeyboardInsertText(text string) error {
+	if err := p.Page.Keyboard().InsertText(text); err != nil {
+		p.reportError(err, "xk6-playwright: error with inserting the text")
+		return err
+	}
+	return nil
+}
+
+//---------------------------------------------------------------------
+//                         MOUSE
+//---------------------------------------------------------------------
+
+// MouseMove wrapper around playwright mouse move function that moves the mouse to the given coordinates
+func (p *Playwright) MouseMove(x float64, y float64, opts playwright.MouseMoveOptions) error {
+	if err := p.Page.Mouse().Move(x, y, opts); err != nil {
+		p.reportError(err, "xk6-playwright: error with moving the mouse")
+		return err
+	}
+	return nil
+}
+
+// MouseDown wrapper around playwright mouse down function that presses a mouse button at the current position
+func (p *Playwright) MouseDown(opts playwright.MouseDownOptions) error {
+	if err := p.Page.Mouse().Down(opts); err != nil {
+		p.reportError(err, "xk6-playwright: error with pressing the mouse button")
+		return err
+	}
+	return nil
+}
+
+// MouseUp wrapper around playwright mouse up function that releases a mouse button at the current position
+func (p *Playwright) MouseUp(opts playwright.MouseUpOptions) error {
+	if err := p.Page.Mouse().Up(opts); err != nil {
+		p.reportError(err, "xk6-playwright: error with releasing the mouse button")
+		return err
+	}
+	return nil
+}
+
+// MouseClick wrapper around playwright mouse click function that clicks at the given coordinates
+func (p *Playwright) MouseClick(x float64, y float64, opts playwright.MouseClickOptions) error {
+	if err := p.Page.Mouse().Click(x, y, opts); err != nil {
+		p.reportError(err, "xk6-playwright: error with clicking the mouse")
+		return err
+	}
+	return nil
+}
+
+// ClickAt moves the mouse to the given page coordinates and clicks there, e.g. on a canvas or a map without DOM targets,
+// failing if the coordinates are outside of the viewport
+func (p *Playwright) ClickAt(x float64, y float64, opts playwright.MouseClickOptions) error {
+	width, height, err := p.viewport()
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with getting the viewport size")
+		return err
+	}
+	if x < 0 || y < 0 || x >= width || y >= height {
+		err := fmt.Errorf("coordinates (%v, %v) are outside of the %vx%v viewport", x, y, width, height)
+		p.reportError(err, "xk6-playwright: error with clicking at the coordinates")
+		return err
+	}
+	if err := p.Page.Mouse().Click(x, y, opts); err != nil {
+		p.reportError(err, "xk6-playwright: error with clicking at the coordinates")
+		return err
+	}
+	return nil
+}
+
+// viewport returns the viewport width and height of the current page, measuring the window when the context has no fixed viewport
+func (p *Playwright) viewport() (float64, float64, error) {
+	if size := p.Page.ViewportSize(); size.Width > 0 && size.Height > 0 {
+		return float64(size.Width), float64(size.Height), nil
+	}
+	size, err := p.Page.Evaluate("JSON.stringify([window.innerWidth, window.innerHeight])")
+	if err != nil {
+		return 0, 0, err
+	}
+	sizeToString := fmt.Sprintf("%v", size)
+	return gjson.Get(sizeToString, "0").Float(), gjson.Get(sizeToString, "1").Float(), nil
+}
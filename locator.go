@@ -0,0 +1,249 @@
+package playwright
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// defaultTestIdAttribute is the attribute GetByTestId matches on unless changed with SetTestIdAttribute
+const defaultTestIdAttribute = "data-testid"
+
+// implicitRoles maps ARIA roles to the elements that carry them implicitly, elements with an explicit role attribute always match
+var implicitRoles = map[string][]string{
+	"button":     {"button", "input[type=button]", "input[type=submit]", "input[type=reset]", "input[type=image]", "summary"},
+	"link":       {"a[href]", "area[href]"},
+	"heading":    {"h1", "h2", "h3", "h4", "h5", "h6"},
+	"textbox":    {"input:not([type])", "input[type=text]", "input[type=email]", "input[type=tel]", "input[type=url]", "input[type=password]", "textarea"},
+	"checkbox":   {"input[type=checkbox]"},
+	"radio":      {"input[type=radio]"},
+	"combobox":   {"select"},
+	"option":     {"option"},
+	"list":       {"ul", "ol"},
+	"listitem":   {"li"},
+	"img":        {"img[alt]"},
+	"table":      {"table"},
+	"row":        {"tr"},
+	"cell":       {"td"},
+	"form":       {"form"},
+	"dialog":     {"dialog"},
+	"navigation": {"nav"},
+	"main":       {"main"},
+}
+
+// GetByRoleOptions are the options of GetByRole
+type GetByRoleOptions struct {
+	// Name narrows the match down to elements containing the given text
+	Name *string `json:"name"`
+	// Exact requires the element text to match Name exactly
+	Exact *bool `json:"exact"`
+}
+
+// GetByTextOptions are the options of GetByText
+type GetByTextOptions struct {
+	// Exact requires a case-sensitive, whole-string match instead of a case-insensitive substring one
+	Exact *bool `json:"exact"`
+}
+
+// Locator is a handle around a playwright locator, its actions auto-wait and re-resolve the selector every time they run
+type Locator struct {
+	Self  playwright.Locator
+	owner *Playwright
+}
+
+// Locator creates a locator for the given selector on the current page
+func (p *Playwright) Locator(selector string) (*Locator, error) {
+	locator, err := p.Page.Locator(selector)
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with creating the locator")
+		return nil, err
+	}
+	return &Locator{Self: locator, owner: p}, nil
+}
+
+// LocatorWaitFor waits for the element matching the selector to reach the state (attached, detached, visible or hidden) within timeoutMs milliseconds,
+// 0 meaning the default timeout. The element is looked up again while waiting, so it is more reliable than WaitForSelector on elements that re-render.
+func (p *Playwright) LocatorWaitFor(selector string, state string, timeoutMs float64) error {
+	locator, err := p.Page.Locator(selector)
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with creating the locator")
+		return err
+	}
+	opts := playwright.PageWaitForSelectorOptions{
+		State: (*playwright.WaitForSelectorState)(&state),
+	}
+	if timeoutMs > 0 {
+		opts.Timeout = &timeoutMs
+	}
+	if err := locator.WaitFor(opts); err != nil {
+		var timeoutErr *playwright.TimeoutError
+		if errors.As(err, &timeoutErr) {
+			err = fmt.Errorf("timed out waiting for %q to be %s: %w", selector, state, err)
+		}
+		p.reportError(err, "xk6-playwright: error waiting for the locator")
+		return err
+	}
+	return nil
+}
+
+// Click wrapper around playwright click locator function that takes in a set of options
+func (l *Locator) Click(opts playwright.PageClickOptions) error {
+	if err := l.Self.Click(opts); err != nil {
+		l.owner.reportError(err, "xk6-playwright: error with clicking the locator")
+		return err
+	}
+	return nil
+}
+
+// Fill wrapper around playwright fill locator function that takes in text and a set of options
+func (l *Locator) Fill(filledString string, opts playwright.FrameFillOptions) error {
+	if err := l.Self.Fill(filledString, opts); err != nil {
+		l.owner.reportError(err, "xk6-playwright: error with filling the locator")
+		return err
+	}
+	return nil
+}
+
+// TextContent wrapper around playwright textContent locator function that returns the text content of the element
+func (l *Locator) TextContent(opts playwright.FrameTextContentOptions) (string, error) {
+	text, err := l.Self.TextContent(opts)
+	if err != nil {
+		l.owner.reportError(err, "xk6-playwright: error with getting the text content of the locator")
+		return "", err
+	}
+	return text, nil
+}
+
+// Count wrapper around playwright count locator function that returns the number of elements matching the locator
+func (l *Locator) Count() (int, error) {
+	count, err := l.Self.Count()
+	if err != nil {
+		l.owner.reportError(err, "xk6-playwright: error with counting the locator elements")
+		return 0, err
+	}
+	return count, nil
+}
+
+// Nth returns a locator for the zero-based n-th element matching the locator
+func (l *Locator) Nth(index int) (*Locator, error) {
+	locator, err := l.Self.Nth(index)
+	if err != nil {
+		l.owner.reportError(err, "xk6-playwright: error with getting the nth locator")
+		return nil, err
+	}
+	return &Locator{Self: locator, owner: l.owner}, nil
+}
+
+// First returns a locator for the first element matching the locator
+func (l *Locator) First() (*Locator, error) {
+	locator, err := l.Self.First()
+	if err != nil {
+		l.owner.reportError(err, "xk6-playwright: error with getting the first locator")
+		return nil, err
+	}
+	return &Locator{Self: locator, owner: l.owner}, nil
+}
+
+// Last returns a locator for the last element matching the locator
+func (l *Locator) Last() (*Locator, error) {
+	locator, err := l.Self.Last()
+	if err != nil {
+		l.owner.reportError(err, "xk6-playwright: error with getting the last locator")
+		return nil, err
+	}
+	return &Locator{Self: locator, owner: l.owner}, nil
+}
+
+// GetByRole creates a locator for the elements with the given ARIA role, either explicit through the role attribute or implicit for common html elements.
+// NOTE: playwright-go does not ship role selectors yet, so the name option is matched against the element text rather than its accessible name.
+func (p *Playwright) GetByRole(role string, opts GetByRoleOptions) (*Locator, error) {
+	return p.Locator(roleSelector(role, opts))
+}
+
+// GetByText creates a locator for the elements containing the given text
+func (p *Playwright) GetByText(text string, opts GetByTextOptions) (*Locator, error) {
+	return p.Locator(textSelector(text, opts.Exact != nil && *opts.Exact))
+}
+
+// GetByTestId creates a locator for the elements whose test id attribute (data-testid by default) equals the given id
+func (p *Playwright) GetByTestId(testId string) (*Locator, error) {
+	attribute := p.testIdAttribute
+	if attribute == "" {
+		attribute = defaultTestIdAttribute
+	}
+	return p.Locator(fmt.Sprintf("[%s=%s]", attribute, cssString(testId)))
+}
+
+// SetTestIdAttribute changes the attribute GetByTestId matches on, e.g. "data-test" or "data-qa"
+func (p *Playwright) SetTestIdAttribute(attribute string) {
+	p.testIdAttribute = attribute
+}
+
+// cssString quotes the value as a CSS string, escaping the quotes and backslashes with a backslash and the control characters as hex escapes,
+// unlike a Go quoted string whose \n, \t, \x and \u escapes mean something else in a selector
+func cssString(value string) string {
+	var quoted strings.Builder
+	quoted.WriteByte('"')
+	for _, r := range value {
+		switch {
+		case r == '"' || r == '\\':
+			quoted.WriteByte('\\')
+			quoted.WriteRune(r)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&quoted, "\\%x ", r)
+		default:
+			quoted.WriteRune(r)
+		}
+	}
+	quoted.WriteByte('"')
+	return quoted.String()
+}
+
+// textSelector builds a text selector matching the text literally, a quoted one when exact and otherwise a case-insensitive regular expression
+// whose punctuation is hex escaped and whose whitespace runs match any whitespace, so quotes, slashes or ">>" in the text cannot turn it into another kind of selector or a chain
+func textSelector(text string, exact bool) string {
+	if exact {
+		// the text is matched with its whitespace collapsed, so collapsing it here keeps newlines and tabs out of the quoted string
+		return "text=" + cssString(strings.Join(strings.Fields(text), " "))
+	}
+	var pattern strings.Builder
+	space := false
+	for _, r := range strings.TrimSpace(text) {
+		if unicode.IsSpace(r) {
+			if !space {
+				pattern.WriteString("\\s+")
+			}
+			space = true
+			continue
+		}
+		space = false
+		if r < utf8.RuneSelf && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			fmt.Fprintf(&pattern, "\\x%02x", r)
+		} else {
+			pattern.WriteRune(r)
+		}
+	}
+	if pattern.Len() == 0 {
+		pattern.WriteString("(?:)")
+	}
+	return "text=/" + pattern.String() + "/i"
+}
+
+// roleSelector builds a css selector matching the given role and, if set, the name option
+func roleSelector(role string, opts GetByRoleOptions) string {
+	selectors := append([]string{fmt.Sprintf("[role=%s]", cssString(role))}, implicitRoles[role]...)
+	if opts.Name != nil {
+		name := ":has-text(" + cssString(*opts.Name) + ")"
+		if opts.Exact != nil && *opts.Exact {
+			name = ":text-is(" + cssString(*opts.Name) + ")"
+		}
+		for i := range selectors {
+			selectors[i] += name
+		}
+	}
+	return strings.Join(selectors, ", ")
+}
@@ -0,0 +1,74 @@
+package playwright
+
+import (
+	"errors"
+	"time"
+
+	"go.k6.io/k6/stats"
+)
+
+// Measure runs fn as a named transaction, e.g. "login" or "checkout", and records its duration as a sample of the playwright_<name>_duration trend,
+// which the k6 summary then reports like the built-in metrics. The duration is recorded even if fn fails, and the value or error of fn is returned.
+func (p *Playwright) Measure(name string, fn func() (interface{}, error)) (interface{}, error) {
+	return p.MeasureWithTags(name, nil, fn)
+}
+
+// MeasureWithTags runs fn as a named transaction like Measure and adds the given tags to its sample, overriding the tags set with SetTags
+func (p *Playwright) MeasureWithTags(name string, tags map[string]string, fn func() (interface{}, error)) (interface{}, error) {
+	metric, err := p.trend("playwright_" + name + "_duration")
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with registering the transaction metric")
+		return nil, err
+	}
+	start := time.Now()
+	value, err := fn()
+	if pushErr := p.pushSample(metric, stats.D(time.Since(start)), tags); pushErr != nil {
+		p.reportError(pushErr, "xk6-playwright: error with recording the transaction duration")
+		return nil, pushErr
+	}
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error in the measured transaction")
+		return nil, err
+	}
+	return value, nil
+}
+
+// SetTags sets the tags, e.g. {page: "checkout"}, added to every sample the extension emits from now on so the k6 summary can be broken down by them,
+// they replace the tags set before and an empty object clears them
+func (p *Playwright) SetTags(tags map[string]string) {
+	p.tags = make(map[string]string, len(tags))
+	for key, value := range tags {
+		p.tags[key] = value
+	}
+}
+
+// trend returns the time trend metric with the given name, registering it on first use
+func (p *Playwright) trend(name string) (*stats.Metric, error) {
+	if p.registry == nil {
+		return nil, errors.New("metrics are only available when running inside k6")
+	}
+	return p.registry.NewMetric(name, stats.Trend, stats.Time)
+}
+
+// pushSample emits a sample of the metric tagged with the tags of the running VU, the tags set with SetTags and the extra tags, in increasing precedence,
+// which only runs inside the default function
+func (p *Playwright) pushSample(metric *stats.Metric, value float64, extra map[string]string) error {
+	if p.vu == nil || p.vu.State() == nil {
+		return errors.New("metrics can only be recorded inside the default function")
+	}
+	state := p.vu.State()
+	tags := state.CloneTags()
+	for key, value := range p.tags {
+		tags[key] = value
+	}
+	for key, value := range extra {
+		tags[key] = value
+	}
+	stats.PushIfNotDone(p.vu.Context(), state.Samples, stats.Sample{
+		Metric: metric,
+		Time:   time.Now(),
+		Tags:   stats.NewSampleTags(tags),
+		Value:  value,
+	})
+	return nil
+}
@@ -0,0 +1,116 @@
+package playwright
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// mockFixture is a mocked response read by MockFromDir
+type mockFixture struct {
+	URLPattern string            `json:"urlPattern"`
+	Status     *int              `json:"status"`
+	Headers    map[string]string `json:"headers"`
+	BodyFile   string            `json:"bodyFile"`
+	body       []byte
+}
+
+// MockFromDir fulfills the requests of the current browser context from the fixtures of the directory, every JSON file with a urlPattern field
+// is a fixture like {"urlPattern": "**/api/users", "status": 200, "headers": {...}, "bodyFile": "users.json"}, the body file path being relative
+// to the directory. The body files referenced by a fixture are never taken as fixtures themselves, and the other JSON files are skipped.
+// A request matching several fixtures gets the first one in file name order, and the fixtures of a later call come after those installed before.
+func (p *Playwright) MockFromDir(dir string) error {
+	rules, err := readFixtures(dir)
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with reading the mock fixtures")
+		return err
+	}
+	r, err := p.contextRouter()
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with installing the mocks")
+		return err
+	}
+	r.mu.Lock()
+	r.mocks = append(r.mocks, rules...)
+	r.mu.Unlock()
+	return nil
+}
+
+// readFixtures reads the mock fixtures of the directory in file name order, see MockFromDir
+func readFixtures(dir string) ([]mockRule, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	parsed := make(map[string]mockFixture, len(files))
+	bodyFiles := make(map[string]bool)
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var fixture mockFixture
+		if json.Unmarshal(data, &fixture) != nil || fixture.URLPattern == "" {
+			continue
+		}
+		parsed[file] = fixture
+		if fixture.BodyFile != "" {
+			bodyFiles[filepath.Join(dir, fixture.BodyFile)] = true
+		}
+	}
+	var rules []mockRule
+	for _, file := range files {
+		fixture, ok := parsed[file]
+		if !ok || bodyFiles[file] {
+			continue
+		}
+		matches, err := urlMatcher(fixture.URLPattern)
+		if err != nil {
+			return nil, fmt.Errorf("mock fixture %s: %w", filepath.Base(file), err)
+		}
+		fixture.body = []byte{}
+		if fixture.BodyFile != "" {
+			fixture.body, err = ioutil.ReadFile(filepath.Join(dir, fixture.BodyFile))
+			if err != nil {
+				return nil, fmt.Errorf("mock fixture %s: %w", filepath.Base(file), err)
+			}
+			contentType := mime.TypeByExtension(filepath.Ext(fixture.BodyFile))
+			if contentType != "" && !hasHeader(fixture.Headers, "content-type") {
+				if fixture.Headers == nil {
+					fixture.Headers = map[string]string{}
+				}
+				fixture.Headers["content-type"] = contentType
+			}
+		}
+		rules = append(rules, mockRule{matches: matches, fixture: fixture})
+	}
+	return rules, nil
+}
+
+// ClearMocks removes the mocks installed by MockFromDir so the requests reach the network again, leaving the other routes of the context alone
+func (p *Playwright) ClearMocks() error {
+	r, err := p.contextRouter()
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with removing the mocks")
+		return err
+	}
+	r.mu.Lock()
+	r.mocks = nil
+	r.mu.Unlock()
+	return nil
+}
+
+// hasHeader reports whether the headers have the given header, whatever its case
+func hasHeader(headers map[string]string, name string) bool {
+	for key := range headers {
+		if strings.EqualFold(key, name) {
+			return true
+		}
+	}
+	return false
+}
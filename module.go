@@ -0,0 +1,47 @@
+package playwright
+
+import (
+	"github.com/sirupsen/logrus"
+	"go.k6.io/k6/js/modules"
+)
+
+// RootModule is the global module instance that creates a Playwright client for every VU importing the extension
+type RootModule struct{}
+
+// ModuleInstance is the per-VU module instance exposing the VU's Playwright client to JS
+type ModuleInstance struct {
+	pw *Playwright
+}
+
+var (
+	_ modules.Module   = &RootModule{}
+	_ modules.Instance = &ModuleInstance{}
+)
+
+// NewModuleInstance returns a new Playwright client for the given VU
+func (*RootModule) NewModuleInstance(vu modules.VU) modules.Instance {
+	pw := &Playwright{vu: vu, iterationMode: "fresh-context"}
+	if initEnv := vu.InitEnv(); initEnv != nil {
+		pw.registry = initEnv.Registry
+	}
+	return &ModuleInstance{pw: pw}
+}
+
+// Exports exposes the VU's Playwright client as the default export of the module
+func (mi *ModuleInstance) Exports() modules.Exports {
+	return modules.Exports{Default: mi.pw}
+}
+
+// logger returns the logger of the VU, the one of its state while it runs an iteration and the one of its init environment in the init context,
+// or the logrus standard logger outside k6
+func (p *Playwright) logger() logrus.FieldLogger {
+	if p != nil && p.vu != nil {
+		if state := p.vu.State(); state != nil && state.Logger != nil {
+			return state.Logger
+		}
+		if initEnv := p.vu.InitEnv(); initEnv != nil && initEnv.Logger != nil {
+			return initEnv.Logger
+		}
+	}
+	return logrus.StandardLogger()
+}
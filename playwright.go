@@ -1,407 +1,3068 @@
 package playwright
 
 import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/fs"
 	"io/ioutil"
+	"math"
+	"mime"
+	neturl "net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/playwright-community/playwright-go"
+	"github.com/sirupsen/logrus"
 	"github.com/tidwall/gjson"
 	"go.k6.io/k6/js/modules"
+	"go.k6.io/k6/lib/metrics"
 )
 
 // Register the extension on module initialization, available to
 // import from JS as "k6/x/playwright".
 func init() {
-	modules.Register("k6/x/playwright", new(Playwright))
+	modules.Register("k6/x/playwright", new(RootModule))
 }
 
+// outputDir is the directory relative artifact paths (screenshots, pdfs, videos, traces and HARs) are written to, shared by all VUs
+var (
+	outputDir   string
+	outputDirMu sync.RWMutex
+)
+
 // Playwright is the k6 extension for a playwright-go client.
 type Playwright struct {
-	Self           *playwright.Playwright
-	Browser        playwright.Browser
-	BrowserContext playwright.BrowserContext
-	Page           playwright.Page
+	Self              *playwright.Playwright
+	Browser           playwright.Browser
+	BrowserContext    playwright.BrowserContext
+	Page              playwright.Page
+	engine            string
+	har               *harRecorder
+	tracePath         string
+	testIdAttribute   string
+	dialogAction      string
+	dialogPrompt      string
+	timeout           *float64
+	navTimeout        *float64
+	captureConsole    bool
+	capturePageErr    bool
+	consoleMessages   []string
+	captureResponse   bool
+	responses         []ResponseRecord
+	captureFailed     bool
+	failedRequests    []map[string]string
+	inflight          map[playwright.Page]int
+	initScripts       []string
+	routers           map[playwright.BrowserContext]*router
+	namedContexts     map[string]*namedContext
+	proxy             *string
+	contextDefaults   playwright.BrowserNewContextOptions
+	slowMo            *float64
+	headless          bool
+	vu                modules.VU
+	registry          *metrics.Registry
+	tags              map[string]string
+	pages             []playwright.Page
+	handles           []playwright.JSHandle
+	emulationSessions map[playwright.Page]playwright.CDPSession
+	errorShotDir      string
+	capturingError    bool
+	geolocations      map[playwright.BrowserContext]*geolocationOverride
+	iterationMode     string
+	launchGen         uint64
+	stopWatch         chan struct{}
+	profileDir        string
+	launchEngine      string
+	launchArgs        playwright.BrowserTypeLaunchOptions
+	mu                sync.Mutex
+	// lifecycleMu guards launching and closing, it is not mu because closing a context waits on the dispatcher,
+	// which runs the event handlers that take mu
+	lifecycleMu sync.Mutex
 }
 
 // Launch starts the playwright client and launches a browser
 func (p *Playwright) Launch(args playwright.BrowserTypeLaunchOptions) error {
-	pw, err := playwright.Run()
-	if err != nil {
-		ReportError(err, "xk6-playwright: cannot start playwright")
-		return err
+	return p.launch("chromium", args)
+}
+
+// LaunchBrowser starts the playwright client and launches a browser of the given engine (chromium, firefox or webkit) with sensible defaults,
+// chromium also gets the no-sandbox and disable-dev-shm-usage args it needs to run inside containers. Use Launch for full control over the options.
+func (p *Playwright) LaunchBrowser(engine string, headless bool) error {
+	args := playwright.BrowserTypeLaunchOptions{
+		Headless: &headless,
 	}
-	browser, err := pw.Chromium.Launch(args)
-	if err != nil {
-		ReportError(err, "xk6-playwright: cannot launch chromium")
-		return err
+	if engine == "" || engine == "chromium" {
+		args.Args = []string{"--no-sandbox", "--disable-dev-shm-usage"}
 	}
-	p.Self = pw
-	p.Browser = browser
-	return nil
+	return p.launch(engine, args)
 }
 
-// LaunchPersistent starts the playwright client and launches a browser with a persistent context
-func (p *Playwright) LaunchPersistent(dir string, args playwright.BrowserTypeLaunchPersistentContextOptions) error {
-	pw, err := playwright.Run()
-	if err != nil {
-		ReportError(err, "xk6-playwright: cannot start playwright")
-		return err
+// LaunchConfig is the flat launch configuration of LaunchWithConfig
+type LaunchConfig struct {
+	// Engine is the browser to launch, chromium (the default), firefox or webkit
+	Engine   string `js:"engine" json:"engine"`
+	Headless *bool  `js:"headless" json:"headless"`
+	// Args are extra command line arguments of the browser
+	Args []string `js:"args" json:"args"`
+	// Env are the environment variables of the browser process
+	Env            map[string]string `js:"env" json:"env"`
+	ExecutablePath string            `js:"executablePath" json:"executablePath"`
+	// SlowMo delays every action by the given milliseconds to follow a script while debugging, it should be 0 in load tests
+	SlowMo float64 `js:"slowMo" json:"slowMo"`
+	// Channel is the chromium release channel to launch instead of the bundled chromium, e.g. chrome, chrome-beta or msedge
+	Channel string `js:"channel" json:"channel"`
+}
+
+// LaunchWithConfig starts the playwright client and launches a browser configured by a flat object, e.g. {engine: "chromium", headless: true, channel: "chrome"},
+// which is easier to build from JS than the full launch options of Launch
+func (p *Playwright) LaunchWithConfig(config LaunchConfig) error {
+	args := playwright.BrowserTypeLaunchOptions{
+		Headless: config.Headless,
+		Args:     config.Args,
 	}
-	browser, err := pw.Chromium.LaunchPersistentContext(dir, args)
-	if err != nil {
-		ReportError(err, "xk6-playwright: cannot launch chromium")
+	if len(config.Env) > 0 {
+		args.Env = config.Env
+	}
+	if config.ExecutablePath != "" {
+		args.ExecutablePath = &config.ExecutablePath
+	}
+	if config.SlowMo > 0 {
+		args.SlowMo = &config.SlowMo
+	}
+	if config.Channel != "" {
+		args.Channel = &config.Channel
+	}
+	return p.launch(config.Engine, args)
+}
+
+// SetSlowMo delays every action of the browsers launched afterwards by the given milliseconds, which makes a script easy to follow while debugging it
+// with a headed browser. It should be left at 0 in real load tests, as the delay is added to every measured action.
+func (p *Playwright) SetSlowMo(ms float64) {
+	p.slowMo = &ms
+}
+
+// LaunchWithStorageState starts the playwright client, launches a browser of the given engine (chromium, firefox or webkit)
+// and creates a context and a page pre-populated with the storage state saved at statePath
+func (p *Playwright) LaunchWithStorageState(engine string, statePath string, args playwright.BrowserTypeLaunchOptions) error {
+	if err := p.launch(engine, args); err != nil {
 		return err
 	}
-	p.Self = pw
-	p.BrowserContext = browser
-	return nil
+	return p.NewContextFromStorageState(statePath)
 }
 
-// Connect attaches Playwright to an existing browser instance
-func (p *Playwright) Connect(url string, args playwright.BrowserTypeConnectOverCDPOptions) error {
-	pw, err := playwright.Run()
-	if err != nil {
-		ReportError(err, "xk6-playwright: cannot start playwright")
+// LaunchWithCredentials starts the playwright client, launches a browser of the given engine (chromium, firefox or webkit)
+// and opens a page in a context that answers HTTP basic auth challenges with the given username and password
+func (p *Playwright) LaunchWithCredentials(engine string, username string, password string, args playwright.BrowserTypeLaunchOptions) error {
+	if err := p.launch(engine, args); err != nil {
 		return err
 	}
-	browser, err := pw.Chromium.ConnectOverCDP(url, args)
-	if err != nil {
-		ReportError(err, "xk6-playwright: cannot launch chromium")
+	opts := playwright.BrowserNewContextOptions{
+		HttpCredentials: &playwright.BrowserNewContextOptionsHttpCredentials{
+			Username: &username,
+			Password: &password,
+		},
+	}
+	if err := p.newContext(opts); err != nil {
+		p.reportError(err, "xk6-playwright: cannot create browser context with http credentials")
 		return err
 	}
-	context := browser.Contexts()[0]
-
-	p.Self = pw
-	p.Browser = browser
-	p.Page = context.Pages()[0]
 	return nil
 }
 
-// NewPage opens a new page within the browser
-func (p *Playwright) NewPage() error {
-	page, err := p.newPage()
-	if err != nil {
-		ReportError(err, "xk6-playwright: cannot create page")
+// LaunchWithUserAgent starts the playwright client, launches a browser of the given engine (chromium, firefox or webkit) and opens a page in a context
+// sending the given User-Agent, which is also used by the contexts created afterwards
+func (p *Playwright) LaunchWithUserAgent(engine string, userAgent string, args playwright.BrowserTypeLaunchOptions) error {
+	if err := p.launch(engine, args); err != nil {
+		return err
+	}
+	p.contextDefaults.UserAgent = &userAgent
+	if err := p.newContext(playwright.BrowserNewContextOptions{}); err != nil {
+		p.reportError(err, "xk6-playwright: cannot create browser context with user agent")
 		return err
 	}
-	p.Page = page
 	return nil
 }
 
-// Kill closes browser instance and stops puppeteer client
-func (p *Playwright) Kill() error {
-	if err := p.closeBrowser(); err != nil {
-		ReportError(err, "xk6-playwright: cannot close browser")
+// LaunchWithIgnoreHTTPSErrors starts the playwright client, launches a browser of the given engine (chromium, firefox or webkit) and opens a page in a context
+// that, when ignoreHTTPSErrors is true, navigates to https sites with invalid or self-signed certificates instead of failing. The contexts created afterwards
+// keep the setting. Certificate errors are never ignored by default.
+func (p *Playwright) LaunchWithIgnoreHTTPSErrors(engine string, ignoreHTTPSErrors bool, args playwright.BrowserTypeLaunchOptions) error {
+	if err := p.launch(engine, args); err != nil {
 		return err
 	}
-	if err := p.Self.Stop(); err != nil {
-		ReportError(err, "xk6-playwright: cannot stop playwright")
+	p.contextDefaults.IgnoreHttpsErrors = &ignoreHTTPSErrors
+	if err := p.newContext(playwright.BrowserNewContextOptions{}); err != nil {
+		p.reportError(err, "xk6-playwright: cannot create browser context ignoring https errors")
 		return err
 	}
 	return nil
 }
 
-//---------------------------------------------------------------------
-//                         ACTIONS
-//---------------------------------------------------------------------
-
-// Goto wrapper around playwright goto page function that takes in a url and a set of options
-func (p *Playwright) Goto(url string, opts playwright.PageGotoOptions) error {
-	if _, err := p.Page.Goto(url, opts); err != nil {
-		ReportError(err, "xk6-playwright: error when goto url")
+// LaunchWithProxy starts the playwright client and launches a browser of the given engine (chromium, firefox or webkit) routing all its traffic through the given proxy,
+// the proxy server is either a full url such as http://myproxy.com:3128 or socks5://myproxy.com:3128 or the short form myproxy.com:3128
+func (p *Playwright) LaunchWithProxy(engine string, proxy playwright.BrowserTypeLaunchOptionsProxy, args playwright.BrowserTypeLaunchOptions) error {
+	if err := validateProxyServer(proxy.Server); err != nil {
+		p.reportError(err, "xk6-playwright: invalid proxy")
 		return err
 	}
+	args.Proxy = &proxy
+	if err := p.launch(engine, args); err != nil {
+		return err
+	}
+	p.proxy = proxy.Server
 	return nil
 }
 
-// WaitForSelector wrapper around playwright waitForSelector page function that takes in a selector and a set of options
-func (p *Playwright) WaitForSelector(selector string, opts playwright.PageWaitForSelectorOptions) error {
-	if _, err := p.Page.WaitForSelector(selector, opts); err != nil {
-		ReportError(err, "xk6-playwright: error waiting for selector")
+// LaunchWithHAR starts the playwright client, launches a browser of the given engine (chromium, firefox or webkit)
+// and opens a page in a context whose network traffic is recorded to a HAR file at harPath.
+// NOTE: the HAR file is only written once Kill closes the browser context.
+func (p *Playwright) LaunchWithHAR(engine string, harPath string, args playwright.BrowserTypeLaunchOptions) error {
+	if err := p.launch(engine, args); err != nil {
+		return err
+	}
+	if err := p.newContext(playwright.BrowserNewContextOptions{}); err != nil {
+		p.reportError(err, "xk6-playwright: cannot create browser context")
 		return err
 	}
+	p.har = newHarRecorder(p.BrowserContext, outputPath(harPath))
 	return nil
 }
 
-func (p *Playwright) WaitForNavigation(opts playwright.PageWaitForNavigationOptions) error {
-	if _, err := p.Page.WaitForNavigation(opts); err != nil {
-		ReportError(err, "xk6-playwright: error waiting for navigation")
+// LaunchDebug starts the playwright client, launches a browser of the given engine (chromium, firefox or webkit) and opens a page in a context recording
+// everything needed to diagnose a failure under outputDir: a video of every page in videos/, the network traffic in network.har and a playwright trace
+// with screenshots and DOM snapshots in trace.zip. The artifacts are written when the context is closed by CloseContext, CloseBrowser or Kill.
+func (p *Playwright) LaunchDebug(engine string, outputDir string, args playwright.BrowserTypeLaunchOptions) error {
+	dir := outputPath(outputDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		p.reportError(err, "xk6-playwright: error with creating the debug output directory")
+		return err
+	}
+	if err := p.launch(engine, args); err != nil {
+		return err
+	}
+	// the video directory is resolved against the output directory by newContext
+	opts := playwright.BrowserNewContextOptions{
+		RecordVideo: &playwright.BrowserNewContextOptionsRecordVideo{
+			Dir: playwright.String(filepath.Join(outputDir, "videos")),
+		},
+	}
+	if err := p.newContext(opts); err != nil {
+		p.reportError(err, "xk6-playwright: cannot create browser context")
+		return err
+	}
+	p.har = newHarRecorder(p.BrowserContext, filepath.Join(dir, "network.har"))
+	tracing := playwright.TracingStartOptions{
+		Screenshots: playwright.Bool(true),
+		Snapshots:   playwright.Bool(true),
+	}
+	if err := p.BrowserContext.Tracing().Start(tracing); err != nil {
+		p.reportError(err, "xk6-playwright: error with starting the trace")
 		return err
 	}
+	p.tracePath = filepath.Join(dir, "trace.zip")
 	return nil
 }
 
-func (p *Playwright) WaitForLoadState(state string) {
-	p.Page.WaitForLoadState(state)
-}
-
-func (p *Playwright) CountAll(selector string) (int32, error) {
-	elements, err := p.Page.QuerySelectorAll(selector)
+// LaunchPersistent starts the playwright client and launches a browser with a persistent context.
+// The current page is set to the first page of the context, persistent contexts usually restore one, or to a newly opened page otherwise.
+func (p *Playwright) LaunchPersistent(dir string, args playwright.BrowserTypeLaunchPersistentContextOptions) error {
+	p.lifecycleMu.Lock()
+	defer p.lifecycleMu.Unlock()
+	if err := p.closeRunning(); err != nil {
+		return err
+	}
+	pw, err := p.driver()
 	if err != nil {
-		ReportError(err, "xk6-playwright: error querying selector")
-		return 0, err
+		p.reportError(err, "xk6-playwright: cannot start playwright")
+		return err
 	}
-	return int32(len(elements)), nil
-}
-
-func (p *Playwright) CountByState(selector string, state string) (int32, error) {
-	elements, err := p.Page.QuerySelectorAll(selector)
+	mergeOptions(&args, p.contextDefaults)
+	if args.RecordVideo != nil {
+		video := *args.RecordVideo
+		video.Dir = outputPathPtr(video.Dir)
+		args.RecordVideo = &video
+	}
+	args.DownloadsPath = outputPathPtr(args.DownloadsPath)
+	args.TracesDir = outputPathPtr(args.TracesDir)
+	browser, err := pw.Chromium.LaunchPersistentContext(dir, args)
 	if err != nil {
-		ReportError(err, "xk6-playwright: error querying selector")
-		return 0, err
+		p.reportError(err, "xk6-playwright: cannot launch chromium")
+		return err
 	}
-	var count int32
-	for _, element := range elements {
-		shouldCount := false
-		var err error
-		switch state {
-		case "visible":
-			shouldCount, err = element.IsVisible()
-		case "hidden":
-			shouldCount, err = element.IsHidden()
-		case "enabled":
-			shouldCount, err = element.IsEnabled()
-		case "disabled":
-			shouldCount, err = element.IsDisabled()
-		case "editable":
-			shouldCount, err = element.IsEditable()
-		case "checked":
-			shouldCount, err = element.IsChecked()
-		default:
-			err = errors.New("invalid state")
-			ReportError(err, "xk6-playwright: invalid state")
-			return 0, err
-		}
-
-		if err != nil {
-			ReportError(err, "xk6-playwright: error checking visibility")
-			return 0, err
-		}
-		if shouldCount {
-			count++
+	p.Self = pw
+	p.BrowserContext = browser
+	p.engine = "chromium"
+	p.headless = args.Headless == nil || *args.Headless
+	p.killOnVUExit()
+	for i := range p.initScripts {
+		if err := browser.AddInitScript(playwright.BrowserContextAddInitScriptOptions{Script: &p.initScripts[i]}); err != nil {
+			p.reportError(err, "xk6-playwright: error with adding the init script")
+			return err
 		}
 	}
-	return count, nil
-}
-
-// Click wrapper around playwright click page function that takes in a selector and a set of options
-func (p *Playwright) Click(selector string, opts playwright.PageClickOptions) error {
-	if err := p.Page.Click(selector, opts); err != nil {
-		ReportError(err, "xk6-playwright: error with clicking")
+	if pages := browser.Pages(); len(pages) > 0 {
+		p.setPage(pages[0])
+		return nil
+	}
+	page, err := browser.NewPage()
+	if err != nil {
+		p.reportError(err, "xk6-playwright: cannot create page")
 		return err
 	}
+	p.setPage(page)
 	return nil
 }
 
-// Type wrapper around playwright type page function that takes in a selector, string, and a set of options
-func (p *Playwright) Type(selector string, typedString string, opts playwright.PageTypeOptions) error {
-	if err := p.Page.Type(selector, typedString, opts); err != nil {
-		ReportError(err, "xk6-playwright: error with typing")
+// LaunchPersistentPerVU launches a persistent context like LaunchPersistent in a profile directory of its own for every VU, baseDir/vu-<id>, so VUs do not
+// collide on the profile lock. A profile directory created by the launch is removed again by Kill.
+func (p *Playwright) LaunchPersistentPerVU(baseDir string, args playwright.BrowserTypeLaunchPersistentContextOptions) error {
+	id, err := p.vuID()
+	if err != nil {
+		p.reportError(err, "xk6-playwright: cannot get the vu id")
+		return err
+	}
+	dir := filepath.Join(baseDir, fmt.Sprintf("vu-%d", id))
+	_, err = os.Stat(dir)
+	created := os.IsNotExist(err)
+	if err := p.LaunchPersistent(dir, args); err != nil {
+		if created {
+			os.RemoveAll(dir)
+		}
 		return err
 	}
+	if created {
+		p.profileDir = dir
+	}
 	return nil
 }
 
-// PressKey wrapper around playwright Press page function that takes in a selector, key, and a set of options
-func (p *Playwright) PressKey(selector string, key string, opts playwright.PagePressOptions) error {
-	if err := p.Page.Press(selector, key, opts); err != nil {
-		ReportError(err, "xk6-playwright: error with pressing the key")
+// Connect attaches Playwright to an existing browser instance, closing the browser launched or connected before if it is still running
+func (p *Playwright) Connect(url string, args playwright.BrowserTypeConnectOverCDPOptions) error {
+	p.lifecycleMu.Lock()
+	defer p.lifecycleMu.Unlock()
+	if err := p.closeRunning(); err != nil {
+		return err
+	}
+	pw, err := p.driver()
+	if err != nil {
+		p.reportError(err, "xk6-playwright: cannot start playwright")
+		return err
+	}
+	browser, err := pw.Chromium.ConnectOverCDP(url, args)
+	if err != nil {
+		p.reportError(err, "xk6-playwright: cannot launch chromium")
+		return err
+	}
+	if err := p.attach(pw, browser, "chromium"); err != nil {
+		p.reportError(err, "xk6-playwright: cannot attach to chromium")
 		return err
 	}
 	return nil
 }
 
-// Sleep wrapper around playwright waitForTimeout page function that sleeps for the given `timeout` in milliseconds
-func (p *Playwright) Sleep(time float64) {
-	p.Page.WaitForTimeout(time)
-}
-
-// Screenshot wrapper around playwright screenshot page function that attempts to take and save a png image of the current screen.
-func (p *Playwright) Screenshot(filename string, perm fs.FileMode, opts playwright.PageScreenshotOptions) error {
-	image, err := p.Page.Screenshot(opts)
+// ConnectWS attaches Playwright to a browser of the given engine (chromium, firefox or webkit) exposed by a Playwright server over a websocket endpoint,
+// closing the browser launched or connected before if it is still running
+func (p *Playwright) ConnectWS(engine string, wsEndpoint string, args playwright.BrowserTypeConnectOptions) error {
+	p.lifecycleMu.Lock()
+	defer p.lifecycleMu.Unlock()
+	if err := p.closeRunning(); err != nil {
+		return err
+	}
+	pw, err := p.driver()
+	if err != nil {
+		p.reportError(err, "xk6-playwright: cannot start playwright")
+		return err
+	}
+	launcher, err := browserType(pw, engine)
 	if err != nil {
-		ReportError(err, "xk6-playwright: error with taking a screenshot")
+		p.reportError(err, "xk6-playwright: invalid browser engine")
 		return err
 	}
-	err = ioutil.WriteFile("Screenshot_"+time.Now().Format("2017-09-07 17:06:06")+".png", image, perm)
+	browser, err := launcher.Connect(wsEndpoint, args)
 	if err != nil {
-		ReportError(err, "xk6-playwright: error with writing the screenshot to the file system")
+		p.reportError(err, "xk6-playwright: cannot connect to "+launcher.Name())
+		return err
+	}
+	if err := p.attach(pw, browser, launcher.Name()); err != nil {
+		p.reportError(err, "xk6-playwright: cannot attach to "+launcher.Name())
 		return err
 	}
 	return nil
 }
 
-// Focus wrapper around playwright focus page function that takes in a selector and a set of options
-func (p *Playwright) Focus(selector string, opts playwright.PageFocusOptions) error {
-	if err := p.Page.Focus(selector); err != nil {
-		ReportError(err, "xk6-playwright: error with focusing")
+// NewContext creates a new browser context with the given options and opens a new page within it
+func (p *Playwright) NewContext(opts playwright.BrowserNewContextOptions) error {
+	if err := p.newContext(opts); err != nil {
+		p.reportError(err, "xk6-playwright: cannot create browser context")
 		return err
 	}
 	return nil
 }
 
-// Fill wrapper around playwright fill page function that takes in a selector, text, and a set of options
-func (p *Playwright) Fill(selector string, filledString string, opts playwright.FrameFillOptions) error {
-	if err := p.Page.Fill(selector, filledString, opts); err != nil {
-		ReportError(err, "xk6-playwright: error with filling")
+// SetDefaultContextOptions sets the options, e.g. the viewport, locale, user agent or permissions, of every browser context created afterwards
+// by NewContext, NewContextWithDevice, the Launch-like functions and LaunchPersistent. The options passed to a call override the defaults,
+// and the fields left out keep their previous default.
+func (p *Playwright) SetDefaultContextOptions(opts playwright.BrowserNewContextOptions) {
+	mergeOptions(&opts, p.contextDefaults)
+	p.contextDefaults = opts
+}
+
+// SetUserAgent sets the User-Agent of the browser contexts created afterwards. Playwright only sets the User-Agent when a context is created,
+// so it fails if a context is already open; close it first with CloseContext or pass the userAgent option to NewContext.
+func (p *Playwright) SetUserAgent(userAgent string) error {
+	if p.BrowserContext != nil || p.Page != nil {
+		err := errors.New("the user agent cannot be changed on an open browser context, close the context first or pass the userAgent option to newContext")
+		p.reportError(err, "xk6-playwright: error with setting the user agent")
 		return err
 	}
+	p.contextDefaults.UserAgent = &userAgent
 	return nil
 }
 
-// SelectOptions wrapper around playwright selectOptions page function that takes in a selector, values, and a set of options
-func (p *Playwright) SelectOptions(selector string, values playwright.SelectOptionValues, opts playwright.FrameSelectOptionOptions) error {
-	_, err := p.Page.SelectOption(selector, values, opts)
+// CloseContext closes the current browser context and its pages while keeping the browser running, flushing the HAR file if one is being recorded
+func (p *Playwright) CloseContext() error {
+	p.lifecycleMu.Lock()
+	defer p.lifecycleMu.Unlock()
+	return p.closeContext()
+}
+
+// closeContext closes the current browser context, the caller holds lifecycleMu
+func (p *Playwright) closeContext() error {
+	context, err := p.browserContext()
 	if err != nil {
-		ReportError(err, "xk6-playwright: error with selecting options")
+		p.reportError(err, "xk6-playwright: cannot get browser context")
+		return err
+	}
+	if err := p.stopTracing(context); err != nil {
+		p.reportError(err, "xk6-playwright: error with writing the trace")
+		return err
+	}
+	if err := context.Close(); err != nil {
+		p.reportError(err, "xk6-playwright: cannot close browser context")
 		return err
 	}
+	for name, named := range p.namedContexts {
+		if named.context == context {
+			delete(p.namedContexts, name)
+		}
+	}
+	if p.har != nil {
+		if err := p.har.write(); err != nil {
+			p.reportError(err, "xk6-playwright: error with writing the HAR file")
+			return err
+		}
+		p.har = nil
+	}
+	p.BrowserContext = nil
+	p.Page = nil
+	p.pages = nil
+	p.handles = nil
+	delete(p.routers, context)
+	delete(p.geolocations, context)
 	return nil
 }
 
-// Check wrapper around playwright check page function that takes in a selector and a set of options
-func (p *Playwright) Check(selector string, opts playwright.FrameCheckOptions) error {
-	if err := p.Page.Check(selector, opts); err != nil {
-		ReportError(err, "xk6-playwright: error with checking the field")
+// SetIterationMode sets what BeginIteration does: "fresh-context" (the default) keeps the browser running and replaces the browser context,
+// which is fast and isolates cookies and storage between iterations, while "relaunch" kills and relaunches the whole browser
+func (p *Playwright) SetIterationMode(mode string) error {
+	if mode != "fresh-context" && mode != "relaunch" {
+		err := fmt.Errorf("invalid iteration mode %q, expected fresh-context or relaunch", mode)
+		p.reportError(err, "xk6-playwright: invalid iteration mode")
 		return err
 	}
+	p.iterationMode = mode
 	return nil
 }
 
-// Uncheck wrapper around playwright uncheck page function that takes in a selector and a set of options
-func (p *Playwright) Uncheck(selector string, opts playwright.FrameUncheckOptions) error {
-	if err := p.Page.Uncheck(selector, opts); err != nil {
-		ReportError(err, "xk6-playwright: error with unchecking the field")
+// BeginIteration resets the browser state according to the iteration mode, relaunching the browser with the options of the last launch-like call
+// if it is not running anymore, and leaves a new page ready. It is meant to be called at the start of every iteration after launching the browser once.
+func (p *Playwright) BeginIteration() error {
+	if p.iterationMode == "relaunch" || p.Browser == nil {
+		if p.Browser != nil {
+			if err := p.CloseBrowser(); err != nil {
+				return err
+			}
+		}
+		if err := p.launch(p.launchEngine, p.launchArgs); err != nil {
+			return err
+		}
+	} else if err := p.closeContexts(); err != nil {
+		return err
+	}
+	if err := p.newContext(playwright.BrowserNewContextOptions{}); err != nil {
+		p.reportError(err, "xk6-playwright: cannot create browser context")
 		return err
 	}
 	return nil
 }
 
-// DragAndDrop wrapper around playwright draganddrop page function that takes in two selectors(source and target) and a set of options
-func (p *Playwright) DragAndDrop(sourceSelector string, targetSelector string, opts playwright.FrameDragAndDropOptions) error {
-	if err := p.Page.DragAndDrop(sourceSelector, targetSelector, opts); err != nil {
-		ReportError(err, "xk6-playwright: error with dragging and dropping")
+// NewContextFromStorageState creates a new browser context populated with the storage state (cookies and local storage) saved at statePath
+func (p *Playwright) NewContextFromStorageState(statePath string) error {
+	opts := playwright.BrowserNewContextOptions{
+		StorageStatePath: &statePath,
+	}
+	if err := p.newContext(opts); err != nil {
+		p.reportError(err, "xk6-playwright: cannot create browser context from storage state")
 		return err
 	}
 	return nil
 }
 
-// Evaluate wrapper around playwright evaluate page function that takes in an expresion and a set of options and evaluates the expression/function returning the resulting information.
-func (p *Playwright) Evaluate(expression string, opts playwright.PageEvaluateOptions) interface{} {
-	returnedValue, err := p.Page.Evaluate(expression, opts)
-	if err != nil {
-		ReportError(err, "xk6-playwright: error with evaluating the expression")
-		return nil
+// NewContextWithDevice creates a new browser context emulating the given device (user agent, viewport, scale factor and touch) and opens a new page within it
+func (p *Playwright) NewContextWithDevice(deviceName string) error {
+	if p.Self == nil {
+		err := errors.New("playwright is not running")
+		p.reportError(err, "xk6-playwright: cannot emulate device")
+		return err
 	}
-	return returnedValue
-}
-
-// Reload wrapper around playwright reload page function
-func (p *Playwright) Reload() error {
-	if _, err := p.Page.Reload(); err != nil {
-		ReportError(err, "xk6-playwright: error when reloading the page")
+	device, ok := p.Self.Devices[deviceName]
+	if !ok {
+		names := make([]string, 0, len(p.Self.Devices))
+		for name := range p.Self.Devices {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		err := fmt.Errorf("unknown device %q, valid devices are: %s", deviceName, strings.Join(names, ", "))
+		p.reportError(err, "xk6-playwright: cannot emulate device")
+		return err
+	}
+	opts := playwright.BrowserNewContextOptions{
+		UserAgent:         &device.UserAgent,
+		Viewport:          device.Viewport,
+		DeviceScaleFactor: &device.DeviceScaleFactor,
+		IsMobile:          &device.IsMobile,
+		HasTouch:          &device.HasTouch,
+	}
+	if err := p.newContext(opts); err != nil {
+		p.reportError(err, "xk6-playwright: cannot create browser context for device")
 		return err
 	}
 	return nil
 }
 
-// FirstPaint function that gathers the Real User Monitoring Metrics for First Paint of the current page
-func (p *Playwright) FirstPaint() uint64 {
-	entries, err := p.Page.Evaluate("JSON.stringify(performance.getEntriesByName('first-paint'))")
+// SaveStorageState saves the storage state (cookies and local storage) of the current browser context to the given path
+func (p *Playwright) SaveStorageState(path string) error {
+	context, err := p.browserContext()
 	if err != nil {
-		ReportError(err, "xk6-playwright: error with getting the first-paint entries")
-		return 0
+		p.reportError(err, "xk6-playwright: cannot get browser context")
+		return err
 	}
-	entriesToString := fmt.Sprintf("%v", entries)
-	return gjson.Get(entriesToString, "0.startTime").Uint()
+	if _, err := context.StorageState(path); err != nil {
+		p.reportError(err, "xk6-playwright: error with saving the storage state")
+		return err
+	}
+	return nil
 }
 
-// FirstContentfulPaint function that gathers the Real User Monitoring Metrics for First Contentful Paint of the current page
-func (p *Playwright) FirstContentfulPaint() uint64 {
-	entries, err := p.Page.Evaluate("JSON.stringify(performance.getEntriesByName('first-contentful-paint'))")
+// SetGeolocation overrides the geolocation of the current browser context. In chromium it goes through the devtools protocol to keep the exact coordinates,
+// on every page of the context including the pages opened afterwards, and in the other browsers through the context itself. The geolocation is only
+// exposed to pages that were granted the "geolocation" permission, so it is usually paired with GrantPermissions.
+// NOTE: playwright-go only accepts whole numbers outside chromium, so there it fails for fractional coordinates and rounds the accuracy with a warning;
+// pass the geolocation option to NewContext instead for exact coordinates.
+func (p *Playwright) SetGeolocation(latitude float64, longitude float64, accuracy float64) error {
+	context, err := p.browserContext()
 	if err != nil {
-		ReportError(err, "xk6-playwright: error with getting the first-contentful-paint entries")
-		return 0
+		p.reportError(err, "xk6-playwright: cannot get browser context")
+		return err
 	}
-	entriesToString := fmt.Sprintf("%v", entries)
-	return gjson.Get(entriesToString, "0.startTime").Uint()
+	if p.engine == "chromium" {
+		params := map[string]interface{}{"latitude": latitude, "longitude": longitude, "accuracy": accuracy}
+		p.geolocationOverride(context).set(params)
+		for _, page := range context.Pages() {
+			if err := p.emulate(page, "Emulation.setGeolocationOverride", params); err != nil {
+				p.reportError(err, "xk6-playwright: error with setting the geolocation")
+				return err
+			}
+		}
+		return nil
+	}
+	if latitude != math.Trunc(latitude) || longitude != math.Trunc(longitude) {
+		err := fmt.Errorf("%s only takes whole-number coordinates here, got %v, %v; pass the geolocation option to newContext for exact coordinates", p.engine, latitude, longitude)
+		p.reportError(err, "xk6-playwright: error with setting the geolocation")
+		return err
+	}
+	roundedAccuracy := int(math.Round(accuracy))
+	if float64(roundedAccuracy) != accuracy {
+		p.reportWarning(fmt.Sprintf("xk6-playwright: %s only takes a whole-number geolocation accuracy here, %v is rounded to %d", p.engine, accuracy, roundedAccuracy))
+	}
+	geolocation := playwright.SetGeolocationOptions{
+		Latitude:  int(latitude),
+		Longitude: int(longitude),
+		Accuracy:  &roundedAccuracy,
+	}
+	if err := context.SetGeolocation(&geolocation); err != nil {
+		p.reportError(err, "xk6-playwright: error with setting the geolocation")
+		return err
+	}
+	return nil
 }
 
-// TimeToMinimallyInteractive function that gathers the Real User Monitoring Metrics for Time to Minimally Interactive of the current page (based on the first input)
-func (p *Playwright) TimeToMinimallyInteractive() uint64 {
-	entries, err := p.Page.Evaluate("JSON.stringify(performance.getEntriesByType('first-input'))")
+// GrantPermissions wrapper around playwright grantPermissions context function that grants the given permissions (e.g. "geolocation") to the current browser context
+func (p *Playwright) GrantPermissions(permissions []string, opts playwright.BrowserContextGrantPermissionsOptions) error {
+	context, err := p.browserContext()
 	if err != nil {
-		ReportError(err, "xk6-playwright: error with getting the first-input entries for time to minimally interactive metrics")
-		return 0
+		p.reportError(err, "xk6-playwright: cannot get browser context")
+		return err
 	}
-	entriesToString := fmt.Sprintf("%v", entries)
-	return gjson.Get(entriesToString, "0.startTime").Uint()
+	if err := context.GrantPermissions(permissions, opts); err != nil {
+		p.reportError(err, "xk6-playwright: error with granting the permissions")
+		return err
+	}
+	return nil
 }
 
-// FirstInputDelay function that gathers the Real User Monitoring Metrics for First Input Delay of the current page
-func (p *Playwright) FirstInputDelay() uint64 {
-	entries, err := p.Page.Evaluate("JSON.stringify(performance.getEntriesByType('first-input'))")
+// NewPage opens a new page within the browser
+func (p *Playwright) NewPage() error {
+	page, err := p.newPage()
 	if err != nil {
-		ReportError(err, "xk6-playwright: error with getting the first-input entries for first input delay metrics")
-		return 0
+		p.reportError(err, "xk6-playwright: cannot create page")
+		return err
 	}
-	entriesToString := fmt.Sprintf("%v", entries)
-	return gjson.Get(entriesToString, "0.processingStart").Uint() - gjson.Get(entriesToString, "0.startTime").Uint() //https://web.dev/fid/  for calc
+	p.setPage(page)
+	return nil
 }
 
-// Cookies wrapper around playwright cookies fetch function
-func (p *Playwright) Cookies() []*playwright.BrowserContextCookiesResult {
-	cookies, err := p.cookies()
+// CloseBrowser closes the browser, or the persistent context, and its pages but keeps the playwright client running,
+// so the next Launch-like call reuses it instead of starting a new driver. Kill still has to be called at the end to stop the client.
+func (p *Playwright) CloseBrowser() error {
+	p.lifecycleMu.Lock()
+	defer p.lifecycleMu.Unlock()
+	return p.shutdownBrowser()
+}
+
+// shutdownBrowser closes the browser and forgets it, the caller holds lifecycleMu
+func (p *Playwright) shutdownBrowser() error {
+	p.stopWatching()
+	err := p.closeBrowser()
+	// the browser is given up even if closing it failed, so the next launch does not try to close it again
+	p.Browser = nil
+	p.BrowserContext = nil
+	p.Page = nil
+	p.pages = nil
+	p.handles = nil
+	p.routers = nil
+	p.geolocations = nil
+	p.namedContexts = nil
 	if err != nil {
-		ReportError(err, "xk6-playwright: error with getting the cookies")
-		return nil
+		p.reportError(err, "xk6-playwright: cannot close browser")
+		return err
 	}
-	return cookies
+	return nil
 }
 
-//---------------------------------------------------------------------
-//                         Helpers
-//---------------------------------------------------------------------
+// Kill closes browser instance and stops puppeteer client, it is safe to call even if nothing was launched.
+// Use CloseBrowser instead to relaunch a browser later without the cost of restarting the playwright client.
+func (p *Playwright) Kill() error {
+	p.lifecycleMu.Lock()
+	defer p.lifecycleMu.Unlock()
+	return p.kill()
+}
 
-// newPage creates a new page and returns it either with or without a context
-func (p *Playwright) newPage() (playwright.Page, error) {
-	if p.Browser != nil {
-		return p.Browser.NewPage()
+// kill closes the browser and stops the playwright client, the caller holds lifecycleMu
+func (p *Playwright) kill() error {
+	p.stopWatching()
+	var errs []string
+	if p.Browser != nil || p.BrowserContext != nil {
+		if err := p.closeBrowser(); err != nil {
+			p.logError(err, "xk6-playwright: cannot close browser")
+			errs = append(errs, "cannot close browser: "+err.Error())
+		}
 	}
-	if p.BrowserContext != nil {
-		return p.BrowserContext.NewPage()
+	if p.Self != nil {
+		if err := p.Self.Stop(); err != nil {
+			p.logError(err, "xk6-playwright: cannot stop playwright")
+			errs = append(errs, "cannot stop playwright: "+err.Error())
+		}
+	}
+	if p.profileDir != "" {
+		if err := os.RemoveAll(p.profileDir); err != nil {
+			p.logError(err, "xk6-playwright: cannot remove the profile directory")
+			errs = append(errs, "cannot remove the profile directory: "+err.Error())
+		}
+		p.profileDir = ""
+	}
+	p.Self = nil
+	p.Browser = nil
+	p.BrowserContext = nil
+	p.Page = nil
+	p.pages = nil
+	p.handles = nil
+	p.routers = nil
+	p.geolocations = nil
+	p.namedContexts = nil
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// SetScreenshotOnError makes every failing action write a screenshot of the current page to dir, named after the time of the failure,
+// e.g. error-1650000000000.png, turning failed iterations into debuggable artifacts. An empty dir turns it off again.
+func (p *Playwright) SetScreenshotOnError(dir string) {
+	p.errorShotDir = dir
+}
+
+// SetOutputDir sets the directory screenshots, pdfs, videos, traces, downloads and HARs with a relative path are written to, creating it if missing
+func (p *Playwright) SetOutputDir(path string) error {
+	if err := os.MkdirAll(path, 0755); err != nil {
+		p.reportError(err, "xk6-playwright: error with creating the output directory")
+		return err
+	}
+	outputDirMu.Lock()
+	defer outputDirMu.Unlock()
+	outputDir = path
+	return nil
+}
+
+//---------------------------------------------------------------------
+//                         ACTIONS
+//---------------------------------------------------------------------
+
+// SetDefaultTimeout wrapper around playwright setDefaultTimeout page function that sets the timeout in milliseconds of all subsequent actions on the current and new pages.
+// A timeout passed explicitly in the options of an action still takes precedence over the default.
+func (p *Playwright) SetDefaultTimeout(timeout float64) {
+	p.timeout = &timeout
+	if p.Page != nil {
+		p.Page.SetDefaultTimeout(timeout)
+	}
+}
+
+// SetDefaultNavigationTimeout wrapper around playwright setDefaultNavigationTimeout page function that sets the timeout in milliseconds of all subsequent navigations on the current and new pages.
+// It takes precedence over SetDefaultTimeout for navigations, and a timeout passed explicitly in the options of a navigation takes precedence over both.
+func (p *Playwright) SetDefaultNavigationTimeout(timeout float64) {
+	p.navTimeout = &timeout
+	if p.Page != nil {
+		p.Page.SetDefaultNavigationTimeout(timeout)
+	}
+}
+
+// Goto wrapper around playwright goto page function that takes in a url and a set of options
+func (p *Playwright) Goto(url string, opts playwright.PageGotoOptions) error {
+	if _, err := p.Page.Goto(url, opts); err != nil {
+		err = p.proxyError(err)
+		p.reportError(err, "xk6-playwright: error when goto url")
+		return err
+	}
+	return nil
+}
+
+// GotoWithResponse navigates like Goto and returns the status, ok (a 2xx status), url and headers of the main resource response,
+// or an empty object for navigations without a response such as about:blank or a change of the url hash
+func (p *Playwright) GotoWithResponse(url string, opts playwright.PageGotoOptions) (map[string]interface{}, error) {
+	response, err := p.Page.Goto(url, opts)
+	if err != nil {
+		err = p.proxyError(err)
+		p.reportError(err, "xk6-playwright: error when goto url")
+		return nil, err
+	}
+	if response == nil {
+		return map[string]interface{}{}, nil
+	}
+	return map[string]interface{}{
+		"status":  response.Status(),
+		"ok":      response.Ok(),
+		"url":     response.URL(),
+		"headers": response.Headers(),
+	}, nil
+}
+
+// SetContent wrapper around playwright setContent page function that loads the given html into the current page without navigating, honoring the waitUntil option
+func (p *Playwright) SetContent(html string, opts playwright.PageSetContentOptions) error {
+	if err := p.Page.SetContent(html, opts); err != nil {
+		p.reportError(err, "xk6-playwright: error when setting the page content")
+		return err
+	}
+	return nil
+}
+
+// WaitForSelector wrapper around playwright waitForSelector page function that takes in a selector and a set of options
+func (p *Playwright) WaitForSelector(selector string, opts playwright.PageWaitForSelectorOptions) error {
+	if _, err := p.Page.WaitForSelector(selector, opts); err != nil {
+		p.reportError(err, "xk6-playwright: error waiting for selector")
+		return err
+	}
+	return nil
+}
+
+// WaitForHidden waits for the element matching the selector to be hidden or removed from the page, e.g. a loading spinner, whatever the state option says
+func (p *Playwright) WaitForHidden(selector string, opts playwright.PageWaitForSelectorOptions) error {
+	opts.State = playwright.WaitForSelectorStateHidden
+	if _, err := p.Page.WaitForSelector(selector, opts); err != nil {
+		p.reportError(err, "xk6-playwright: error waiting for the element to be hidden")
+		return err
+	}
+	return nil
+}
+
+func (p *Playwright) WaitForNavigation(opts playwright.PageWaitForNavigationOptions) error {
+	if _, err := p.Page.WaitForNavigation(opts); err != nil {
+		p.reportError(err, "xk6-playwright: error waiting for navigation")
+		return err
+	}
+	return nil
+}
+
+// WaitForURL waits for the current page to reach a url matching the pattern, a glob pattern or a regular expression between slashes (see urlMatcher),
+// which also catches client-side route changes, and for its document to be loaded as the waitUntil option asks, load by default. It polls the page
+// rather than waiting for a navigation, so it returns at once if the page is already there; networkidle is waited for like load.
+func (p *Playwright) WaitForURL(pattern string, opts playwright.FrameWaitForURLOptions) error {
+	matches, err := urlMatcher(pattern)
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with compiling the url pattern")
+		return err
+	}
+	ready := `document.readyState === "complete"`
+	if opts.WaitUntil != nil {
+		switch *opts.WaitUntil {
+		case *playwright.WaitUntilStateDomcontentloaded:
+			ready = `document.readyState !== "loading"`
+		case *playwright.WaitUntilStateCommit:
+			ready = "true"
+		}
+	}
+	err = p.poll(p.waitTimeout(opts.Timeout), func(float64) (bool, error) {
+		if !matches(p.Page.URL()) {
+			return false, nil
+		}
+		// evaluating fails while the document is being replaced, the next check retries it
+		loaded, err := p.Page.Evaluate(ready)
+		return loaded == true, err
+	})
+	if err != nil {
+		err = fmt.Errorf("waiting for url to match %q, got %q: %w", pattern, p.Page.URL(), err)
+		p.reportError(err, "xk6-playwright: error waiting for url")
+		return err
+	}
+	return nil
+}
+
+// WaitForTextOptions are the options of WaitForText
+type WaitForTextOptions struct {
+	// Exact waits for the trimmed text to equal the expected text instead of containing it
+	Exact *bool `json:"exact"`
+	// Timeout is the maximum time to wait in milliseconds, defaults to the page default timeout or 30 seconds
+	Timeout *float64 `json:"timeout"`
+}
+
+// WaitForText waits until the text of the element matching the selector contains, or with the exact option equals, the expected text,
+// e.g. until a status cell shows "Complete"
+func (p *Playwright) WaitForText(selector string, text string, opts WaitForTextOptions) error {
+	locator, err := p.Page.Locator(selector)
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with creating the locator")
+		return err
+	}
+	exact := opts.Exact != nil && *opts.Exact
+	timeout := p.waitTimeout(opts.Timeout)
+	var actual string
+	err = p.poll(timeout, func(remaining float64) (bool, error) {
+		content, err := locator.TextContent(playwright.FrameTextContentOptions{Timeout: playwright.Float(remaining)})
+		if err != nil {
+			return false, err
+		}
+		actual = strings.TrimSpace(content)
+		if exact {
+			return actual == text, nil
+		}
+		return strings.Contains(actual, text), nil
+	})
+	if err != nil {
+		err = fmt.Errorf("waiting for %q to have text %q, got %q: %w", selector, text, actual, err)
+		p.reportError(err, "xk6-playwright: error waiting for text")
+		return err
+	}
+	return nil
+}
+
+// WaitForFunction wrapper around playwright waitForFunction page function that waits until the expression returns a truthy value and returns that value
+func (p *Playwright) WaitForFunction(expression string, arg interface{}, opts playwright.FrameWaitForFunctionOptions) (interface{}, error) {
+	handle, err := p.Page.WaitForFunction(expression, arg, opts)
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error waiting for function")
+		return nil, err
+	}
+	value, err := handle.JSONValue()
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with getting the value of the function")
+		return nil, err
+	}
+	return value, nil
+}
+
+func (p *Playwright) WaitForLoadState(state string) {
+	p.Page.WaitForLoadState(state)
+}
+
+// WaitForNetworkQuiet waits until the current page has had at most maxInflight requests in flight for idleMs milliseconds. Unlike the networkidle load state,
+// which needs no connection at all for 500ms, it settles on pages that keep a long-polling request or a heartbeat open. It fails after the default timeout, 30 seconds if unset.
+func (p *Playwright) WaitForNetworkQuiet(idleMs float64, maxInflight int) error {
+	timeout := p.waitTimeout(nil)
+	idle := time.Duration(idleMs * float64(time.Millisecond))
+	deadline := time.Now().Add(time.Duration(timeout * float64(time.Millisecond)))
+	quietSince := time.Now()
+	for {
+		p.mu.Lock()
+		inflight := p.inflight[p.Page]
+		p.mu.Unlock()
+		now := time.Now()
+		if inflight > maxInflight {
+			quietSince = now
+		} else if now.Sub(quietSince) >= idle {
+			return nil
+		}
+		if now.After(deadline) {
+			err := fmt.Errorf("timed out after %vms waiting for at most %d request(s) in flight, %d in flight", timeout, maxInflight, inflight)
+			p.reportError(err, "xk6-playwright: error waiting for the network to be quiet")
+			return err
+		}
+		if err := p.sleep(expectPollInterval); err != nil {
+			p.reportError(err, "xk6-playwright: error waiting for the network to be quiet")
+			return err
+		}
+	}
+}
+
+func (p *Playwright) CountAll(selector string) (int32, error) {
+	elements, err := p.Page.QuerySelectorAll(selector)
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error querying selector")
+		return 0, err
+	}
+	return int32(len(elements)), nil
+}
+
+// CountByRole counts the elements with the given ARIA role, optionally narrowed down by name
+func (p *Playwright) CountByRole(role string, opts GetByRoleOptions) (int32, error) {
+	return p.countLocator(roleSelector(role, opts))
+}
+
+// CountByText counts the elements containing the given text
+func (p *Playwright) CountByText(text string) (int32, error) {
+	return p.countLocator(textSelector(text, false))
+}
+
+func (p *Playwright) CountByState(selector string, state string) (int32, error) {
+	elements, err := p.Page.QuerySelectorAll(selector)
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error querying selector")
+		return 0, err
+	}
+	var count int32
+	for _, element := range elements {
+		shouldCount, err := elementState(element, state)
+		if errors.Is(err, errInvalidState) {
+			p.reportError(err, "xk6-playwright: invalid state")
+			return 0, err
+		}
+		if err != nil {
+			p.reportError(err, "xk6-playwright: error checking visibility")
+			return 0, err
+		}
+		if shouldCount {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// IsVisible returns whether the element matching the selector is visible, a missing element is not visible
+func (p *Playwright) IsVisible(selector string) (bool, error) {
+	visible, err := p.Page.IsVisible(selector)
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error checking visibility")
+		return false, err
+	}
+	return visible, nil
+}
+
+// IsHidden returns whether the element matching the selector is hidden
+func (p *Playwright) IsHidden(selector string) (bool, error) {
+	return p.isState(selector, "hidden")
+}
+
+// IsEnabled returns whether the element matching the selector is enabled
+func (p *Playwright) IsEnabled(selector string) (bool, error) {
+	return p.isState(selector, "enabled")
+}
+
+// IsDisabled returns whether the element matching the selector is disabled
+func (p *Playwright) IsDisabled(selector string) (bool, error) {
+	return p.isState(selector, "disabled")
+}
+
+// IsEditable returns whether the element matching the selector is editable
+func (p *Playwright) IsEditable(selector string) (bool, error) {
+	return p.isState(selector, "editable")
+}
+
+// IsChecked returns whether the element matching the selector is checked
+func (p *Playwright) IsChecked(selector string) (bool, error) {
+	return p.isState(selector, "checked")
+}
+
+// Click wrapper around playwright click page function that takes in a selector and a set of options
+func (p *Playwright) Click(selector string, opts playwright.PageClickOptions) error {
+	if err := p.Page.Click(selector, opts); err != nil {
+		p.reportError(err, "xk6-playwright: error with clicking")
+		return err
+	}
+	return nil
+}
+
+// Tap wrapper around playwright tap page function that takes in a selector and a set of options.
+// The browser context must have been created with hasTouch enabled, e.g. through NewContextWithDevice, otherwise playwright rejects the tap.
+func (p *Playwright) Tap(selector string, opts playwright.FrameTapOptions) error {
+	if err := p.Page.Tap(selector, opts); err != nil {
+		if strings.Contains(err.Error(), "hasTouch") {
+			err = fmt.Errorf("tap requires a browser context created with hasTouch enabled, e.g. with newContextWithDevice: %w", err)
+		}
+		p.reportError(err, "xk6-playwright: error with tapping")
+		return err
+	}
+	return nil
+}
+
+// Type wrapper around playwright type page function that takes in a selector, string, and a set of options
+func (p *Playwright) Type(selector string, typedString string, opts playwright.PageTypeOptions) error {
+	if err := p.Page.Type(selector, typedString, opts); err != nil {
+		p.reportError(err, "xk6-playwright: error with typing")
+		return err
+	}
+	return nil
+}
+
+// PressKey wrapper around playwright Press page function that takes in a selector, key, and a set of options
+func (p *Playwright) PressKey(selector string, key string, opts playwright.PagePressOptions) error {
+	if err := p.Page.Press(selector, key, opts); err != nil {
+		p.reportError(err, "xk6-playwright: error with pressing the key")
+		return err
+	}
+	return nil
+}
+
+// PressKeySequence focuses the element matching the selector and presses the keys in order on the keyboard, waiting delayMs milliseconds between them,
+// e.g. ["ArrowDown", "ArrowDown", "Enter"] to pick an item of a list
+func (p *Playwright) PressKeySequence(selector string, keys []string, delayMs float64) error {
+	if err := p.Page.Focus(selector); err != nil {
+		p.reportError(err, "xk6-playwright: error with focusing the element")
+		return err
+	}
+	delay := time.Duration(delayMs * float64(time.Millisecond))
+	for i, key := range keys {
+		if i > 0 {
+			if err := p.sleep(delay); err != nil {
+				p.reportError(err, "xk6-playwright: error with waiting between the keys")
+				return err
+			}
+		}
+		if err := p.Page.Keyboard().Press(key); err != nil {
+			p.reportError(err, "xk6-playwright: error with pressing the key")
+			return err
+		}
+	}
+	return nil
+}
+
+// PressKeyRepeat focuses the element matching the selector and presses the key repeat times, waiting delayMs milliseconds between the presses
+func (p *Playwright) PressKeyRepeat(selector string, key string, repeat int, delayMs float64) error {
+	keys := make([]string, 0, repeat)
+	for i := 0; i < repeat; i++ {
+		keys = append(keys, key)
+	}
+	return p.PressKeySequence(selector, keys, delayMs)
+}
+
+// Sleep wrapper around playwright waitForTimeout page function that sleeps for the given `timeout` in milliseconds
+func (p *Playwright) Sleep(time float64) {
+	p.Page.WaitForTimeout(time)
+}
+
+// Screenshot wrapper around playwright screenshot page function that attempts to take and save a png image of the current screen to filename,
+// or to Screenshot_<date>-<time>.png, e.g. Screenshot_20220415-093012.345.png, if filename is empty.
+func (p *Playwright) Screenshot(filename string, perm fs.FileMode, opts playwright.PageScreenshotOptions) error {
+	image, err := p.Page.Screenshot(opts)
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with taking a screenshot")
+		return err
+	}
+	if filename == "" {
+		filename = "Screenshot_" + time.Now().Format("20060102-150405.000") + ".png"
+	}
+	err = writeFile(outputPath(filename), image, perm)
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with writing the screenshot to the file system")
+		return err
+	}
+	return nil
+}
+
+// ScreenshotBuffer wrapper around playwright screenshot page function that takes a screenshot of the current page (honoring the fullPage and clip options) and returns the raw image bytes
+func (p *Playwright) ScreenshotBuffer(opts playwright.PageScreenshotOptions) ([]byte, error) {
+	image, err := p.Page.Screenshot(opts)
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with taking a screenshot")
+		return nil, err
+	}
+	return image, nil
+}
+
+// ScreenshotElement takes a screenshot of the first element matching the selector and writes it to the given path
+func (p *Playwright) ScreenshotElement(selector string, path string, opts playwright.ElementHandleScreenshotOptions) error {
+	element, err := p.Page.QuerySelector(selector)
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error querying selector")
+		return err
+	}
+	if element == nil {
+		err := fmt.Errorf("no element matches selector %q", selector)
+		p.reportError(err, "xk6-playwright: error with taking an element screenshot")
+		return err
+	}
+	image, err := element.Screenshot(opts)
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with taking an element screenshot")
+		return err
+	}
+	if err := writeFile(outputPath(path), image, 0644); err != nil {
+		p.reportError(err, "xk6-playwright: error with writing the screenshot to the file system")
+		return err
+	}
+	return nil
+}
+
+// PDF wrapper around playwright pdf page function that generates a pdf of the current page and writes it to the given path, only supported by headless chromium
+func (p *Playwright) PDF(path string, opts playwright.PagePdfOptions) error {
+	if p.engine != "chromium" {
+		err := fmt.Errorf("pdf generation is only supported in headless chromium, current browser is %q", p.engine)
+		p.reportError(err, "xk6-playwright: error with generating the pdf")
+		return err
+	}
+	pdf, err := p.Page.PDF(opts)
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with generating the pdf")
+		return err
+	}
+	if err := writeFile(outputPath(path), pdf, 0644); err != nil {
+		p.reportError(err, "xk6-playwright: error with writing the pdf to the file system")
+		return err
+	}
+	return nil
+}
+
+// Focus wrapper around playwright focus page function that takes in a selector and a set of options
+func (p *Playwright) Focus(selector string, opts playwright.PageFocusOptions) error {
+	if err := p.Page.Focus(selector); err != nil {
+		p.reportError(err, "xk6-playwright: error with focusing")
+		return err
+	}
+	return nil
+}
+
+// Fill wrapper around playwright fill page function that takes in a selector, text, and a set of options
+func (p *Playwright) Fill(selector string, filledString string, opts playwright.FrameFillOptions) error {
+	if err := p.Page.Fill(selector, filledString, opts); err != nil {
+		p.reportError(err, "xk6-playwright: error with filling")
+		return err
+	}
+	return nil
+}
+
+// FillForm fills every field of the selector to value map, in selector order, and returns a single error listing the fields that could not be filled
+func (p *Playwright) FillForm(fields map[string]string, opts playwright.FrameFillOptions) error {
+	selectors := make([]string, 0, len(fields))
+	for selector := range fields {
+		selectors = append(selectors, selector)
+	}
+	sort.Strings(selectors)
+	var errs []string
+	for _, selector := range selectors {
+		if err := p.Page.Fill(selector, fields[selector], opts); err != nil {
+			errs = append(errs, fmt.Sprintf("%q: %s", selector, err))
+		}
+	}
+	if len(errs) > 0 {
+		err := fmt.Errorf("cannot fill %d of %d field(s): %s", len(errs), len(fields), strings.Join(errs, "; "))
+		p.reportError(err, "xk6-playwright: error with filling the form")
+		return err
+	}
+	return nil
+}
+
+// InputValue wrapper around playwright inputValue page function that returns the current value of the input, textarea or select element matching the selector,
+// failing for any other element
+func (p *Playwright) InputValue(selector string, opts playwright.FrameInputValueOptions) (string, error) {
+	value, err := p.Page.InputValue(selector, opts)
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with getting the input value")
+		return "", err
+	}
+	return value, nil
+}
+
+// Clear empties an 'input' element based on the provided selector, dispatching the same input events as a user clearing it.
+// playwright-go does not provide Clear yet, so this is done the way playwright itself does it, by filling an empty string.
+func (p *Playwright) Clear(selector string, opts playwright.FrameFillOptions) error {
+	if err := p.Page.Fill(selector, "", opts); err != nil {
+		p.reportError(err, "xk6-playwright: error with clearing the field")
+		return err
+	}
+	return nil
+}
+
+// SelectOptions wrapper around playwright selectOptions page function that takes in a selector, values, and a set of options
+func (p *Playwright) SelectOptions(selector string, values playwright.SelectOptionValues, opts playwright.FrameSelectOptionOptions) error {
+	_, err := p.Page.SelectOption(selector, values, opts)
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with selecting options")
+		return err
+	}
+	return nil
+}
+
+// SelectOptionByLabel selects the options with the given labels (several for a multi-select element) based on the provided selector and returns the selected values
+func (p *Playwright) SelectOptionByLabel(selector string, labels ...string) ([]string, error) {
+	selected, err := p.Page.SelectOption(selector, playwright.SelectOptionValues{Labels: &labels})
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with selecting options by label")
+		return nil, err
+	}
+	return selected, nil
+}
+
+// SelectOptionByIndex selects the options at the given zero-based indexes (several for a multi-select element) based on the provided selector and returns the selected values
+func (p *Playwright) SelectOptionByIndex(selector string, indexes ...int) ([]string, error) {
+	selected, err := p.Page.SelectOption(selector, playwright.SelectOptionValues{Indexes: &indexes})
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with selecting options by index")
+		return nil, err
+	}
+	return selected, nil
+}
+
+// Check wrapper around playwright check page function that takes in a selector and a set of options
+func (p *Playwright) Check(selector string, opts playwright.FrameCheckOptions) error {
+	if err := p.Page.Check(selector, opts); err != nil {
+		p.reportError(err, "xk6-playwright: error with checking the field")
+		return err
+	}
+	return nil
+}
+
+// Uncheck wrapper around playwright uncheck page function that takes in a selector and a set of options
+func (p *Playwright) Uncheck(selector string, opts playwright.FrameUncheckOptions) error {
+	if err := p.Page.Uncheck(selector, opts); err != nil {
+		p.reportError(err, "xk6-playwright: error with unchecking the field")
+		return err
+	}
+	return nil
+}
+
+// SetChecked wrapper around playwright setChecked page function that checks or unchecks a checkbox or radio button based on the checked argument, whatever its current state
+func (p *Playwright) SetChecked(selector string, checked bool, opts playwright.FrameSetCheckedOptions) error {
+	if err := p.Page.SetChecked(selector, checked, opts); err != nil {
+		p.reportError(err, "xk6-playwright: error with setting the checked state of the field")
+		return err
+	}
+	return nil
+}
+
+// DispatchEvent wrapper around playwright dispatchEvent frame function that dispatches a DOM event of the given type, e.g. "change" or a custom "my-widget:ready",
+// on the element matching the selector with the given event init properties, which the action helpers such as Fill and Click do not emit
+func (p *Playwright) DispatchEvent(selector string, eventType string, eventInit interface{}, opts playwright.PageDispatchEventOptions) error {
+	// the page dispatchEvent of playwright-go drops the event init, so the event is dispatched through the main frame
+	if err := p.Page.MainFrame().DispatchEvent(selector, eventType, eventInit, opts); err != nil {
+		p.reportError(err, "xk6-playwright: error with dispatching the event")
+		return err
+	}
+	return nil
+}
+
+// DragAndDrop wrapper around playwright draganddrop page function that takes in two selectors(source and target) and a set of options
+func (p *Playwright) DragAndDrop(sourceSelector string, targetSelector string, opts playwright.FrameDragAndDropOptions) error {
+	if err := p.Page.DragAndDrop(sourceSelector, targetSelector, opts); err != nil {
+		p.reportError(err, "xk6-playwright: error with dragging and dropping")
+		return err
+	}
+	return nil
+}
+
+// ManualDragAndDrop drags the element matching the source selector onto the one matching the target selector with real mouse steps: hovering the source,
+// pressing the mouse button, moving to the target center in the given number of steps and releasing the button. It completes drags some drag libraries
+// ignore with DragAndDrop, as they only react to actual pointer movement.
+func (p *Playwright) ManualDragAndDrop(sourceSelector string, targetSelector string, steps int) error {
+	if err := p.Page.Hover(sourceSelector); err != nil {
+		p.reportError(err, "xk6-playwright: error with hovering the drag source")
+		return err
+	}
+	sourceX, sourceY, err := p.elementCenter(sourceSelector)
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with getting the drag source position")
+		return err
+	}
+	targetX, targetY, err := p.elementCenter(targetSelector)
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with getting the drag target position")
+		return err
+	}
+	if steps < 1 {
+		steps = 1
+	}
+	mouse := p.Page.Mouse()
+	if err := mouse.Move(sourceX, sourceY); err != nil {
+		p.reportError(err, "xk6-playwright: error with moving the mouse to the drag source")
+		return err
+	}
+	if err := mouse.Down(); err != nil {
+		p.reportError(err, "xk6-playwright: error with pressing the mouse button")
+		return err
+	}
+	if err := mouse.Move(targetX, targetY, playwright.MouseMoveOptions{Steps: playwright.Int(steps)}); err != nil {
+		p.reportError(err, "xk6-playwright: error with moving the mouse to the drag target")
+		return err
+	}
+	if err := mouse.Up(); err != nil {
+		p.reportError(err, "xk6-playwright: error with releasing the mouse button")
+		return err
+	}
+	return nil
+}
+
+// InputFileContent is an in-memory file for SetInputFilesFromContent, with the content encoded as base64
+type InputFileContent struct {
+	Name     string `json:"name"`
+	MimeType string `json:"mimeType"`
+	Content  string `json:"content"`
+}
+
+// SetInputFiles wrapper around playwright setInputFiles page function that uploads the files at the given paths to a file input based on the provided selector
+func (p *Playwright) SetInputFiles(selector string, files []string, opts playwright.FrameSetInputFilesOptions) error {
+	inputFiles := make([]playwright.InputFile, 0, len(files))
+	for _, file := range files {
+		buffer, err := ioutil.ReadFile(file)
+		if err != nil {
+			p.reportError(err, "xk6-playwright: error with reading the file to upload")
+			return err
+		}
+		inputFiles = append(inputFiles, playwright.InputFile{
+			Name:     filepath.Base(file),
+			MimeType: mime.TypeByExtension(filepath.Ext(file)),
+			Buffer:   buffer,
+		})
+	}
+	if err := p.setInputFiles(selector, inputFiles, opts); err != nil {
+		p.reportError(err, "xk6-playwright: error with setting the input files")
+		return err
+	}
+	return nil
+}
+
+// SetInputFilesFromContent wrapper around playwright setInputFiles page function that uploads in-memory files to a file input based on the provided selector
+func (p *Playwright) SetInputFilesFromContent(selector string, files []InputFileContent, opts playwright.FrameSetInputFilesOptions) error {
+	inputFiles := make([]playwright.InputFile, 0, len(files))
+	for _, file := range files {
+		buffer, err := base64.StdEncoding.DecodeString(file.Content)
+		if err != nil {
+			p.reportError(err, "xk6-playwright: error with decoding the file content")
+			return err
+		}
+		inputFiles = append(inputFiles, playwright.InputFile{
+			Name:     file.Name,
+			MimeType: file.MimeType,
+			Buffer:   buffer,
+		})
+	}
+	if err := p.setInputFiles(selector, inputFiles, opts); err != nil {
+		p.reportError(err, "xk6-playwright: error with setting the input files")
+		return err
+	}
+	return nil
+}
+
+// DownloadResult describes a file downloaded by ExpectDownload
+type DownloadResult struct {
+	Path              string `json:"path"`
+	SuggestedFilename string `json:"suggestedFilename"`
+}
+
+// ExpectDownload clicks the element matching the trigger selector, waits for the download it starts and saves it to a temporary directory
+func (p *Playwright) ExpectDownload(triggerSelector string, opts playwright.PageClickOptions) (*DownloadResult, error) {
+	download, err := p.Page.ExpectDownload(func() error {
+		return p.Page.Click(triggerSelector, opts)
+	})
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with waiting for the download")
+		return nil, err
+	}
+	if failure, err := download.Failure(); err != nil || failure != "" {
+		if err == nil {
+			err = errors.New(failure)
+		}
+		err = fmt.Errorf("download of %s failed: %w", download.URL(), err)
+		p.reportError(err, "xk6-playwright: error with downloading the file")
+		return nil, err
+	}
+	dir, err := ioutil.TempDir("", "xk6-playwright-download-")
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with creating the download directory")
+		return nil, err
+	}
+	path := filepath.Join(dir, download.SuggestedFilename())
+	if err := download.SaveAs(path); err != nil {
+		err = fmt.Errorf("saving download of %s failed: %w", download.URL(), err)
+		p.reportError(err, "xk6-playwright: error with saving the download")
+		return nil, err
+	}
+	return &DownloadResult{Path: path, SuggestedFilename: download.SuggestedFilename()}, nil
+}
+
+// ExpectPopup clicks the element matching the trigger selector, waits for the popup or new tab it opens and returns the id of the popup page,
+// which SwitchPage makes the current page. The page that was current gets an id too, so scripts can switch back to it.
+func (p *Playwright) ExpectPopup(triggerSelector string) (int, error) {
+	popup, err := p.Page.ExpectPopup(func() error {
+		return p.Page.Click(triggerSelector)
+	})
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with waiting for the popup")
+		return 0, err
+	}
+	p.watchPage(popup)
+	p.pageID(p.Page)
+	return p.pageID(popup), nil
+}
+
+// SwitchPage makes the page with the given id, as returned by ExpectPopup, the current page
+func (p *Playwright) SwitchPage(id int) error {
+	if id < 0 || id >= len(p.pages) {
+		err := fmt.Errorf("no page with id %d", id)
+		p.reportError(err, "xk6-playwright: error with switching the page")
+		return err
+	}
+	if p.pages[id].IsClosed() {
+		err := fmt.Errorf("page %d is closed", id)
+		p.reportError(err, "xk6-playwright: error with switching the page")
+		return err
+	}
+	p.Page = p.pages[id]
+	return nil
+}
+
+// ScrollIntoView scrolls the element matching the selector into view if it is not already visible
+func (p *Playwright) ScrollIntoView(selector string) error {
+	element, err := p.Page.QuerySelector(selector)
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error querying selector")
+		return err
+	}
+	if element == nil {
+		err := fmt.Errorf("no element matches selector %q", selector)
+		p.reportError(err, "xk6-playwright: error with scrolling into view")
+		return err
+	}
+	if err := element.ScrollIntoViewIfNeeded(); err != nil {
+		p.reportError(err, "xk6-playwright: error with scrolling into view")
+		return err
+	}
+	return nil
+}
+
+// ScrollBy scrolls the current page by the given number of pixels
+func (p *Playwright) ScrollBy(x float64, y float64) error {
+	if _, err := p.Page.Evaluate("([x, y]) => window.scrollBy(x, y)", []float64{x, y}); err != nil {
+		p.reportError(err, "xk6-playwright: error with scrolling the page")
+		return err
+	}
+	return nil
+}
+
+// ScrollTo scrolls the current page to the given coordinates in pixels
+func (p *Playwright) ScrollTo(x float64, y float64) error {
+	if _, err := p.Page.Evaluate("([x, y]) => window.scrollTo(x, y)", []float64{x, y}); err != nil {
+		p.reportError(err, "xk6-playwright: error with scrolling the page")
+		return err
+	}
+	return nil
+}
+
+// Evaluate wrapper around playwright evaluate page function that takes in an expresion and an argument passed to it and evaluates the expression/function returning the resulting information.
+func (p *Playwright) Evaluate(expression string, arg interface{}) (interface{}, error) {
+	returnedValue, err := p.Page.Evaluate(expression, arg)
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with evaluating the expression")
+		return nil, err
+	}
+	return returnedValue, nil
+}
+
+// EvaluateBatch evaluates every expression of the name to expression map, e.g. {"title": "document.title", "links": "document.links.length"},
+// in a single round-trip and returns their values keyed by name, awaiting the expressions that return a promise
+func (p *Playwright) EvaluateBatch(expressions map[string]string) (map[string]interface{}, error) {
+	names := make([]string, 0, len(expressions))
+	for name := range expressions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var script strings.Builder
+	script.WriteString("async () => {\n\tconst values = {};\n")
+	for _, name := range names {
+		key, err := json.Marshal(name)
+		if err != nil {
+			p.reportError(err, "xk6-playwright: error with encoding the expression name")
+			return nil, err
+		}
+		fmt.Fprintf(&script, "\tvalues[%s] = await (%s);\n", key, expressions[name])
+	}
+	script.WriteString("\treturn values;\n}")
+	returnedValue, err := p.Page.Evaluate(script.String())
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with evaluating the expressions")
+		return nil, err
+	}
+	values, ok := returnedValue.(map[string]interface{})
+	if !ok {
+		err := fmt.Errorf("unexpected result of the expressions: %v", returnedValue)
+		p.reportError(err, "xk6-playwright: error with evaluating the expressions")
+		return nil, err
+	}
+	return values, nil
+}
+
+// EvaluateJSONPath evaluates the expression/function and returns the value at the gjson path of its JSON encoded result, e.g. "items.#.id" or "user.name",
+// failing if there is no value at the path
+func (p *Playwright) EvaluateJSONPath(expression string, path string) (string, error) {
+	returnedValue, err := p.Page.Evaluate(expression)
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with evaluating the expression")
+		return "", err
+	}
+	data, err := json.Marshal(returnedValue)
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with encoding the evaluated value")
+		return "", err
+	}
+	value := gjson.GetBytes(data, path)
+	if !value.Exists() {
+		err := fmt.Errorf("no value at path %q", path)
+		p.reportError(err, "xk6-playwright: error with extracting the evaluated value")
+		return "", err
+	}
+	return value.String(), nil
+}
+
+// EvaluateHandle wrapper around playwright evaluateHandle page function that evaluates the expression/function with the given argument and returns a handle to the result, for values that cannot be serialized
+func (p *Playwright) EvaluateHandle(expression string, arg interface{}) (playwright.JSHandle, error) {
+	handle, err := p.Page.EvaluateHandle(expression, arg)
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with evaluating the expression")
+		return nil, err
+	}
+	p.trackHandle(handle)
+	return handle, nil
+}
+
+// SetLocalStorage sets the given key to the given value in the local storage of the current page
+func (p *Playwright) SetLocalStorage(key string, value string) error {
+	if err := p.setStorageItem("localStorage", key, value); err != nil {
+		p.reportError(err, "xk6-playwright: error with setting the local storage item")
+		return err
+	}
+	return nil
+}
+
+// GetLocalStorage returns the value stored under the given key in the local storage of the current page
+func (p *Playwright) GetLocalStorage(key string) (string, error) {
+	value, err := p.getStorageItem("localStorage", key)
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with getting the local storage item")
+		return "", err
+	}
+	return value, nil
+}
+
+// SetSessionStorage sets the given key to the given value in the session storage of the current page
+func (p *Playwright) SetSessionStorage(key string, value string) error {
+	if err := p.setStorageItem("sessionStorage", key, value); err != nil {
+		p.reportError(err, "xk6-playwright: error with setting the session storage item")
+		return err
+	}
+	return nil
+}
+
+// GetSessionStorage returns the value stored under the given key in the session storage of the current page
+func (p *Playwright) GetSessionStorage(key string) (string, error) {
+	value, err := p.getStorageItem("sessionStorage", key)
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with getting the session storage item")
+		return "", err
+	}
+	return value, nil
+}
+
+// SetViewportSize wrapper around playwright setViewportSize page function that resizes the current page to the given width and height in pixels
+func (p *Playwright) SetViewportSize(width int, height int) error {
+	if err := p.Page.SetViewportSize(width, height); err != nil {
+		p.reportError(err, "xk6-playwright: error with setting the viewport size")
+		return err
+	}
+	return nil
+}
+
+// AddScriptTag wrapper around playwright addScriptTag page function that injects a script into the current page from a url, a path or inline content
+func (p *Playwright) AddScriptTag(opts playwright.PageAddScriptTagOptions) error {
+	if _, err := p.Page.AddScriptTag(opts); err != nil {
+		p.reportError(err, "xk6-playwright: error with adding the script tag")
+		return err
+	}
+	return nil
+}
+
+// AddStyleTag wrapper around playwright addStyleTag page function that injects a stylesheet into the current page from a url, a path or inline content
+func (p *Playwright) AddStyleTag(opts playwright.PageAddStyleTagOptions) error {
+	if _, err := p.Page.AddStyleTag(opts); err != nil {
+		p.reportError(err, "xk6-playwright: error with adding the style tag")
+		return err
+	}
+	return nil
+}
+
+// AddInitScript adds a script evaluated before any page script on every new document of the current browser context, so it survives navigations.
+// The script is also added to every context and page created afterwards.
+func (p *Playwright) AddInitScript(script string) error {
+	p.initScripts = append(p.initScripts, script)
+	context, err := p.browserContext()
+	if err != nil {
+		// no context yet, the script is added once one is created
+		return nil
+	}
+	if err := context.AddInitScript(playwright.BrowserContextAddInitScriptOptions{Script: &script}); err != nil {
+		p.reportError(err, "xk6-playwright: error with adding the init script")
+		return err
+	}
+	return nil
+}
+
+// PreauthLocalStorage sets the local storage entries of the origin, e.g. "https://app.example.com", through an init script before any page script runs,
+// so a single-page application reading its token from the local storage boots authenticated without a login round-trip
+func (p *Playwright) PreauthLocalStorage(origin string, entries map[string]string) error {
+	originJSON, err := json.Marshal(strings.TrimSuffix(origin, "/"))
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with encoding the origin")
+		return err
+	}
+	entriesJSON, err := json.Marshal(entries)
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with encoding the local storage entries")
+		return err
+	}
+	script := fmt.Sprintf(`if (window.location.origin === %s) {
+	for (const [key, value] of Object.entries(%s)) window.localStorage.setItem(key, value);
+}`, originJSON, entriesJSON)
+	return p.AddInitScript(script)
+}
+
+// EmulateMedia wrapper around playwright emulateMedia page function that emulates the media type (screen or print), the color scheme (light, dark or no-preference)
+// and the reduced motion preference (reduce or no-preference) of the current page
+func (p *Playwright) EmulateMedia(opts playwright.PageEmulateMediaOptions) error {
+	if err := p.Page.EmulateMedia(opts); err != nil {
+		p.reportError(err, "xk6-playwright: error with emulating the media")
+		return err
+	}
+	return nil
+}
+
+// ThrottleRequests delays every request of the current browser context whose url matches the pattern, a glob or a regular expression between slashes
+// (see urlMatcher), by delayMs milliseconds before letting it go on. Calling it again with the same pattern replaces its delay, a delay of 0 removes it.
+// It only adds latency, the bandwidth is not limited, but unlike devtools network emulation it works in every browser.
+func (p *Playwright) ThrottleRequests(urlPattern string, delayMs float64) error {
+	matches, err := urlMatcher(urlPattern)
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with compiling the url pattern")
+		return err
+	}
+	r, err := p.contextRouter()
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with throttling the requests")
+		return err
+	}
+	r.throttle(urlPattern, matches, time.Duration(delayMs*float64(time.Millisecond)))
+	return nil
+}
+
+// BlockResourceTypes aborts the requests of the current browser context whose resource type is in the list, e.g. "image", "font", "stylesheet" or "media",
+// to save bandwidth and isolate the backend timing in load tests that do not need the page to render faithfully. Calling it again replaces the list.
+func (p *Playwright) BlockResourceTypes(types []string) error {
+	r, err := p.contextRouter()
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with blocking the resource types")
+		return err
+	}
+	blocked := make(map[string]bool, len(types))
+	for _, resourceType := range types {
+		blocked[resourceType] = true
+	}
+	r.mu.Lock()
+	r.blocked = blocked
+	r.mu.Unlock()
+	return nil
+}
+
+// UnblockResourceTypes lets the requests blocked by BlockResourceTypes through again
+func (p *Playwright) UnblockResourceTypes() error {
+	r, err := p.contextRouter()
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with unblocking the resource types")
+		return err
+	}
+	r.mu.Lock()
+	r.blocked = nil
+	r.mu.Unlock()
+	return nil
+}
+
+// Pause wrapper around playwright pause page function that stops the script and opens the Playwright Inspector to step through it while authoring,
+// it needs a headed browser, a display and usually PWDEBUG=1. Pause does nothing but log a warning in headless browsers and when the CI environment
+// variable is set, so a forgotten call cannot hang a pipeline.
+func (p *Playwright) Pause() error {
+	if p.headless || os.Getenv("CI") != "" {
+		p.reportWarning("xk6-playwright: pause is skipped, it only works with a headed browser outside of CI")
+		return nil
+	}
+	if err := p.Page.Pause(); err != nil {
+		p.reportError(err, "xk6-playwright: error with pausing the page")
+		return err
+	}
+	return nil
+}
+
+// Reload wrapper around playwright reload page function
+func (p *Playwright) Reload() error {
+	if _, err := p.Page.Reload(); err != nil {
+		p.reportError(err, "xk6-playwright: error when reloading the page")
+		return err
+	}
+	return nil
+}
+
+// GoBack wrapper around playwright goBack page function that navigates to the previous page in history, doing nothing if there is none
+func (p *Playwright) GoBack(opts playwright.PageGoBackOptions) error {
+	if _, err := p.Page.GoBack(opts); err != nil {
+		p.reportError(err, "xk6-playwright: error when going back")
+		return err
+	}
+	return nil
+}
+
+// GoForward wrapper around playwright goForward page function that navigates to the next page in history, doing nothing if there is none
+func (p *Playwright) GoForward(opts playwright.PageGoForwardOptions) error {
+	if _, err := p.Page.GoForward(opts); err != nil {
+		p.reportError(err, "xk6-playwright: error when going forward")
+		return err
+	}
+	return nil
+}
+
+// URL returns the url of the current page
+func (p *Playwright) URL() string {
+	return p.Page.URL()
+}
+
+// Title wrapper around playwright title page function that returns the title of the current page
+func (p *Playwright) Title() (string, error) {
+	title, err := p.Page.Title()
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with getting the page title")
+		return "", err
+	}
+	return title, nil
+}
+
+// FirstPaint function that gathers the Real User Monitoring Metrics for First Paint of the current page
+func (p *Playwright) FirstPaint() uint64 {
+	entries, err := p.Page.Evaluate("JSON.stringify(performance.getEntriesByName('first-paint'))")
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with getting the first-paint entries")
+		return 0
+	}
+	entriesToString := fmt.Sprintf("%v", entries)
+	return gjson.Get(entriesToString, "0.startTime").Uint()
+}
+
+// FirstContentfulPaint function that gathers the Real User Monitoring Metrics for First Contentful Paint of the current page
+func (p *Playwright) FirstContentfulPaint() uint64 {
+	entries, err := p.Page.Evaluate("JSON.stringify(performance.getEntriesByName('first-contentful-paint'))")
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with getting the first-contentful-paint entries")
+		return 0
+	}
+	entriesToString := fmt.Sprintf("%v", entries)
+	return gjson.Get(entriesToString, "0.startTime").Uint()
+}
+
+// TimeToMinimallyInteractive function that gathers the Real User Monitoring Metrics for Time to Minimally Interactive of the current page (based on the first input),
+// failing if there was no input on the page yet
+func (p *Playwright) TimeToMinimallyInteractive() (float64, error) {
+	entry, err := p.firstInput()
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with getting the first-input entries for time to minimally interactive metrics")
+		return 0, err
+	}
+	return entry.Get("startTime").Float(), nil
+}
+
+// FirstInputDelay function that gathers the Real User Monitoring Metrics for First Input Delay of the current page, failing if there was no input on the page yet
+func (p *Playwright) FirstInputDelay() (float64, error) {
+	entry, err := p.firstInput()
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with getting the first-input entries for first input delay metrics")
+		return 0, err
+	}
+	return entry.Get("processingStart").Float() - entry.Get("startTime").Float(), nil //https://web.dev/fid/  for calc
+}
+
+// navigationTimingScript computes the navigation timing metrics, relative to the start of the navigation, from the navigation performance entry
+const navigationTimingScript = `() => {
+	const n = performance.getEntriesByType('navigation')[0];
+	if (!n) return null;
+	return JSON.stringify({
+		dns: n.domainLookupEnd - n.domainLookupStart,
+		tcp: n.connectEnd - n.connectStart,
+		tls: n.secureConnectionStart > 0 ? n.connectEnd - n.secureConnectionStart : 0,
+		ttfb: n.responseStart - n.requestStart,
+		domContentLoaded: n.domContentLoadedEventEnd,
+		loadEventEnd: n.loadEventEnd,
+	});
+}`
+
+// NavigationTiming gathers the navigation timing metrics of the current page in milliseconds in a single round-trip: dns, tcp and tls for the connection setup,
+// ttfb from the request to the first byte of the response, and domContentLoaded and loadEventEnd since the start of the navigation
+func (p *Playwright) NavigationTiming() (map[string]float64, error) {
+	entry, err := p.Page.Evaluate(navigationTimingScript)
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with getting the navigation timing entry")
+		return nil, err
+	}
+	if entry == nil {
+		err := errors.New("the page has no navigation timing entry")
+		p.reportError(err, "xk6-playwright: error with getting the navigation timing entry")
+		return nil, err
+	}
+	timing := make(map[string]float64)
+	gjson.Parse(fmt.Sprintf("%v", entry)).ForEach(func(key, value gjson.Result) bool {
+		timing[key.String()] = value.Float()
+		return true
+	})
+	return timing, nil
+}
+
+// longTasksScript is the init script recording the duration of every long task of the page, the longtask entries are not buffered so the observer
+// has to be registered before the page starts loading
+const longTasksScript = `window.__xk6LongTasks = [];
+new PerformanceObserver((list) => {
+	for (const entry of list.getEntries()) window.__xk6LongTasks.push(entry.duration);
+}).observe({entryTypes: ['longtask']});`
+
+// TrackLongTasks starts recording the long tasks of the pages navigated afterwards, it has to be called before navigating for TotalBlockingTime and LongTaskCount to work
+func (p *Playwright) TrackLongTasks() error {
+	return p.AddInitScript(longTasksScript)
+}
+
+// TotalBlockingTime gathers the Total Blocking Time of the current page in milliseconds, the sum of the part of every long task exceeding 50ms
+func (p *Playwright) TotalBlockingTime() (float64, error) {
+	durations, err := p.longTasks()
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with getting the long tasks for total blocking time metrics")
+		return 0, err
+	}
+	var total float64
+	for _, duration := range durations {
+		if duration > 50 {
+			total += duration - 50
+		}
+	}
+	return total, nil
+}
+
+// LongTaskCount gathers the number of long tasks, tasks blocking the main thread for more than 50ms, of the current page
+func (p *Playwright) LongTaskCount() (int, error) {
+	durations, err := p.longTasks()
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with getting the long tasks")
+		return 0, err
+	}
+	return len(durations), nil
+}
+
+// JSHeapUsedSize gathers the size in bytes of the javascript heap used by the current page, it is read through the devtools protocol so it is only supported in chromium
+func (p *Playwright) JSHeapUsedSize() (int64, error) {
+	size, err := p.performanceMetric("JSHeapUsedSize")
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with getting the used javascript heap size")
+		return 0, err
+	}
+	return size, nil
+}
+
+// JSHeapTotalSize gathers the size in bytes of the javascript heap allocated by the current page, it is read through the devtools protocol so it is only supported in chromium
+func (p *Playwright) JSHeapTotalSize() (int64, error) {
+	size, err := p.performanceMetric("JSHeapTotalSize")
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with getting the total javascript heap size")
+		return 0, err
+	}
+	return size, nil
+}
+
+// OnDialog sets how alert, confirm, prompt and beforeunload dialogs are handled, action is either "accept" (optionally with the prompt text) or "dismiss".
+// Dialogs block the page until they are handled, so pages are set up to dismiss them automatically unless OnDialog says otherwise.
+func (p *Playwright) OnDialog(action string, promptText string) error {
+	if action != "accept" && action != "dismiss" {
+		err := fmt.Errorf("invalid dialog action %q, expected accept or dismiss", action)
+		p.reportError(err, "xk6-playwright: invalid dialog action")
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.dialogAction = action
+	p.dialogPrompt = promptText
+	return nil
+}
+
+// OnConsole starts capturing the console messages of the current and new pages, prefixed with their type (e.g. "[error] boom"), until they are drained with ConsoleMessages
+func (p *Playwright) OnConsole() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.captureConsole = true
+}
+
+// OnPageError starts capturing the uncaught exceptions of the current and new pages, prefixed with "[pageerror]", until they are drained with ConsoleMessages
+func (p *Playwright) OnPageError() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.capturePageErr = true
+}
+
+// ConsoleMessages returns the captured console messages and page errors and empties the buffer
+func (p *Playwright) ConsoleMessages() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	messages := p.consoleMessages
+	p.consoleMessages = nil
+	if messages == nil {
+		return []string{}
+	}
+	return messages
+}
+
+// ResponseRecord is a response observed by the browser while OnResponse is active
+type ResponseRecord struct {
+	URL    string `json:"url"`
+	Status int    `json:"status"`
+	// TimeToFirstByte is the time in milliseconds from the start of the request to the first response byte, -1 if unknown
+	TimeToFirstByte float64 `json:"timeToFirstByte"`
+}
+
+// OnResponse starts recording the url, status and timing of every response received by the current and new pages
+func (p *Playwright) OnResponse() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.captureResponse = true
+}
+
+// Responses returns the responses recorded since OnResponse was called
+func (p *Playwright) Responses() []ResponseRecord {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]ResponseRecord{}, p.responses...)
+}
+
+// ResponseStatusCounts returns the number of recorded responses by status class ("2xx", "3xx", "4xx" and "5xx")
+func (p *Playwright) ResponseStatusCounts() map[string]int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	counts := map[string]int{"2xx": 0, "3xx": 0, "4xx": 0, "5xx": 0}
+	for _, response := range p.responses {
+		class := fmt.Sprintf("%dxx", response.Status/100)
+		counts[class]++
+	}
+	return counts
+}
+
+// OnRequestFailed starts recording the url and failure text of every request of the current and new pages that fails, until they are drained with FailedRequests
+func (p *Playwright) OnRequestFailed() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.captureFailed = true
+}
+
+// FailedRequests returns the failed requests recorded since OnRequestFailed was called, as objects with a url and a failure, and empties the buffer
+func (p *Playwright) FailedRequests() []map[string]string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	failed := p.failedRequests
+	p.failedRequests = nil
+	if failed == nil {
+		return []map[string]string{}
+	}
+	return failed
+}
+
+// WaitForEventOptions are the options of WaitForEvent
+type WaitForEventOptions struct {
+	// Timeout is the maximum time to wait in milliseconds, defaults to the page default timeout or 30 seconds
+	Timeout *float64 `json:"timeout"`
+}
+
+// WaitForEvent waits for the next event of the current page with the given name, e.g. "popup", "download", "filechooser", "worker" or "close",
+// and returns a summary of its payload such as the url of a popup or the suggested filename of a download
+func (p *Playwright) WaitForEvent(event string, opts WaitForEventOptions) (interface{}, error) {
+	timeout := p.waitTimeout(opts.Timeout)
+	payload := make(chan interface{}, 1)
+	// the playwright-go waitForEvent cannot time out, so a handler of our own is removed again whatever happens
+	handler := func(args ...interface{}) {
+		var value interface{}
+		if len(args) > 0 {
+			value = args[0]
+		}
+		select {
+		case payload <- value:
+		default:
+		}
+	}
+	p.Page.On(event, handler)
+	defer p.Page.RemoveListener(event, handler)
+	select {
+	case value := <-payload:
+		return eventSummary(value), nil
+	case <-time.After(time.Duration(timeout * float64(time.Millisecond))):
+		err := fmt.Errorf("timed out after %vms waiting for the %q event", timeout, event)
+		p.reportError(err, "xk6-playwright: error waiting for event")
+		return nil, err
+	}
+}
+
+// WaitForResponseOptions are the options of WaitForResponse
+type WaitForResponseOptions struct {
+	// Body adds the body of the response to the result, as text or as base64 if it is binary
+	Body bool `js:"body" json:"body"`
+	// ParseJSON adds the body of the response parsed as JSON to the result instead of its text
+	ParseJSON bool `js:"parseJSON" json:"parseJSON"`
+	// MaxBytes caps the returned body, longer bodies are cut and flagged as truncated, defaults to 1MB
+	MaxBytes int `js:"maxBytes" json:"maxBytes"`
+	// Timeout is the maximum time to wait in milliseconds, defaults to the page default timeout or 30 seconds
+	Timeout *float64 `js:"timeout" json:"timeout"`
+}
+
+// WaitForResponse runs the action, e.g. a click, and waits for the current page to receive a response whose url matches the pattern, a glob pattern
+// or a regular expression between slashes (see urlMatcher), returning its status, ok, url and headers and, with the body or parseJSON option, its body
+func (p *Playwright) WaitForResponse(urlPattern string, action func() (interface{}, error), opts WaitForResponseOptions) (map[string]interface{}, error) {
+	matches, err := urlMatcher(urlPattern)
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with compiling the url pattern")
+		return nil, err
+	}
+	responses := make(chan playwright.Response, 1)
+	// the handler runs on the playwright dispatcher, the body is read once the response is handed over
+	handler := func(response playwright.Response) {
+		if matches(response.URL()) {
+			select {
+			case responses <- response:
+			default:
+			}
+		}
+	}
+	p.Page.On("response", handler)
+	defer p.Page.RemoveListener("response", handler)
+	if action != nil {
+		if _, err := action(); err != nil {
+			p.reportError(err, "xk6-playwright: error with running the action")
+			return nil, err
+		}
+	}
+	timeout := p.waitTimeout(opts.Timeout)
+	var response playwright.Response
+	select {
+	case response = <-responses:
+	case <-time.After(time.Duration(timeout * float64(time.Millisecond))):
+		err := fmt.Errorf("timed out after %vms waiting for a response matching %q", timeout, urlPattern)
+		p.reportError(err, "xk6-playwright: error waiting for response")
+		return nil, err
+	}
+	result := map[string]interface{}{
+		"status":  response.Status(),
+		"ok":      response.Ok(),
+		"url":     response.URL(),
+		"headers": response.Headers(),
+	}
+	if !opts.Body && !opts.ParseJSON {
+		return result, nil
+	}
+	body, err := response.Body()
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with reading the response body")
+		return nil, err
+	}
+	if opts.ParseJSON {
+		var value interface{}
+		if err := json.Unmarshal(body, &value); err != nil {
+			p.reportError(err, "xk6-playwright: error with parsing the response body")
+			return nil, err
+		}
+		result["body"] = value
+		return result, nil
+	}
+	maxBytes := opts.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = 1 << 20
+	}
+	result["size"] = len(body)
+	result["truncated"] = len(body) > maxBytes
+	if len(body) > maxBytes {
+		body = body[:maxBytes]
+	}
+	if utf8.Valid(body) {
+		result["body"] = string(body)
+	} else {
+		result["body"] = base64.StdEncoding.EncodeToString(body)
+		result["base64"] = true
+	}
+	return result, nil
+}
+
+// Cookies wrapper around playwright cookies fetch function
+func (p *Playwright) Cookies() []*playwright.BrowserContextCookiesResult {
+	cookies, err := p.cookies()
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with getting the cookies")
+		return nil
+	}
+	return cookies
+}
+
+// CookiesForURLs wrapper around playwright cookies context function that only returns the cookies sent to any of the given urls
+func (p *Playwright) CookiesForURLs(urls []string) ([]*playwright.BrowserContextCookiesResult, error) {
+	context, err := p.browserContext()
+	if err != nil {
+		p.reportError(err, "xk6-playwright: cannot get browser context")
+		return nil, err
+	}
+	cookies, err := context.Cookies(urls...)
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with getting the cookies")
+		return nil, err
+	}
+	return cookies, nil
+}
+
+// ExportCookies writes the cookies of the current browser context to the file as a JSON array, in the format ImportCookies reads back
+func (p *Playwright) ExportCookies(path string) error {
+	cookies, err := p.cookies()
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with getting the cookies")
+		return err
+	}
+	data, err := json.MarshalIndent(cookies, "", "  ")
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with encoding the cookies")
+		return err
+	}
+	if err := writeFile(path, data, 0644); err != nil {
+		p.reportError(err, "xk6-playwright: error with writing the cookies to the file system")
+		return err
+	}
+	return nil
+}
+
+// ImportCookies adds the cookies of a JSON array file, as written by ExportCookies, to the current browser context.
+// Every cookie needs a name, a value and either a url or a domain and a path.
+func (p *Playwright) ImportCookies(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with reading the cookies file")
+		return err
+	}
+	var cookies []playwright.BrowserContextAddCookiesOptionsCookies
+	if err := json.Unmarshal(data, &cookies); err != nil {
+		p.reportError(err, "xk6-playwright: error with decoding the cookies")
+		return err
+	}
+	context, err := p.browserContext()
+	if err != nil {
+		p.reportError(err, "xk6-playwright: cannot get browser context")
+		return err
+	}
+	if err := context.AddCookies(cookies...); err != nil {
+		p.reportError(err, "xk6-playwright: error with adding the cookies")
+		return err
+	}
+	return nil
+}
+
+//---------------------------------------------------------------------
+//                         Helpers
+//---------------------------------------------------------------------
+
+// driver returns the running playwright client, starting one if there is none or it was stopped by Kill
+func (p *Playwright) driver() (*playwright.Playwright, error) {
+	if p.Self != nil {
+		return p.Self, nil
+	}
+	return playwright.Run()
+}
+
+// launch starts the playwright client and launches a browser of the given engine, closing the browser launched before if it is still running
+func (p *Playwright) launch(engine string, args playwright.BrowserTypeLaunchOptions) error {
+	p.lifecycleMu.Lock()
+	defer p.lifecycleMu.Unlock()
+	if err := p.closeRunning(); err != nil {
+		return err
+	}
+	pw, err := p.driver()
+	if err != nil {
+		p.reportError(err, "xk6-playwright: cannot start playwright")
+		return err
+	}
+	launcher, err := browserType(pw, engine)
+	if err != nil {
+		p.reportError(err, "xk6-playwright: invalid browser engine")
+		return err
+	}
+	if args.SlowMo == nil {
+		args.SlowMo = p.slowMo
+	}
+	// the given options are kept for relaunching, the resolved paths would be resolved a second time
+	launchArgs := args
+	args.DownloadsPath = outputPathPtr(args.DownloadsPath)
+	args.TracesDir = outputPathPtr(args.TracesDir)
+	browser, err := launcher.Launch(args)
+	if err != nil {
+		p.reportError(err, "xk6-playwright: cannot launch "+launcher.Name())
+		return err
+	}
+	p.Self = pw
+	p.Browser = browser
+	p.engine = launcher.Name()
+	p.launchEngine = engine
+	p.launchArgs = launchArgs
+	p.headless = args.Headless == nil || *args.Headless
+	p.killOnVUExit()
+	return nil
+}
+
+// closeRunning closes the browser or persistent context that is still running before a new one is launched, so relaunching does not leak it
+func (p *Playwright) closeRunning() error {
+	if p.Browser == nil && p.BrowserContext == nil {
+		return nil
+	}
+	return p.shutdownBrowser()
+}
+
+// closeContexts closes every context of the browser, the current one, the named ones of CreateContext and those opened through the browser directly,
+// so nothing leaks into the next iteration
+func (p *Playwright) closeContexts() error {
+	if p.BrowserContext != nil || len(p.Browser.Contexts()) > 0 {
+		if err := p.CloseContext(); err != nil {
+			return err
+		}
+	}
+	p.lifecycleMu.Lock()
+	defer p.lifecycleMu.Unlock()
+	for _, context := range p.Browser.Contexts() {
+		if err := context.Close(); err != nil {
+			p.reportError(err, "xk6-playwright: cannot close browser context")
+			return err
+		}
+	}
+	p.namedContexts = nil
+	p.routers = nil
+	p.geolocations = nil
+	return nil
+}
+
+// killOnVUExit kills the browser and the playwright client once the run of the VU is over, as the teardown function runs in a VU of its own
+// and cannot reach them. It only applies to launches inside the default function, a watcher is tied to its launch and is stopped
+// when the browser is closed or replaced, so it never kills a browser launched after it. The caller holds lifecycleMu.
+func (p *Playwright) killOnVUExit() {
+	if p.vu == nil || p.vu.State() == nil {
+		return
+	}
+	ctx := p.vu.Context()
+	if ctx == nil {
+		return
+	}
+	gen := p.launchGen
+	stop := make(chan struct{})
+	p.stopWatch = stop
+	go func() {
+		select {
+		case <-stop:
+			return
+		case <-ctx.Done():
+		}
+		p.lifecycleMu.Lock()
+		defer p.lifecycleMu.Unlock()
+		if p.launchGen != gen {
+			return
+		}
+		p.kill()
+	}()
+}
+
+// stopWatching stops the watcher of the current launch and marks the launch as over, the caller holds lifecycleMu
+func (p *Playwright) stopWatching() {
+	p.launchGen++
+	if p.stopWatch != nil {
+		close(p.stopWatch)
+		p.stopWatch = nil
+	}
+}
+
+// attach makes the connected browser and the first page of its first context the current ones,
+// creating the context and the page when the browser does not have any yet
+func (p *Playwright) attach(pw *playwright.Playwright, browser playwright.Browser, engine string) error {
+	var context playwright.BrowserContext
+	if contexts := browser.Contexts(); len(contexts) > 0 {
+		context = contexts[0]
+	} else {
+		created, err := browser.NewContext()
+		if err != nil {
+			return fmt.Errorf("connected browser has no context and creating one failed: %w", err)
+		}
+		context = created
+	}
+	var page playwright.Page
+	if pages := context.Pages(); len(pages) > 0 {
+		page = pages[0]
+	} else {
+		created, err := context.NewPage()
+		if err != nil {
+			return fmt.Errorf("connected browser has no page and creating one failed: %w", err)
+		}
+		page = created
+	}
+
+	p.Self = pw
+	p.Browser = browser
+	p.setPage(page)
+	p.engine = engine
+	// a connected browser may be headed, so Pause is attempted
+	p.headless = false
+	return nil
+}
+
+// firstInput returns the first-input performance entry of the current page, failing if there was no input yet
+func (p *Playwright) firstInput() (gjson.Result, error) {
+	entries, err := p.Page.Evaluate("JSON.stringify(performance.getEntriesByType('first-input'))")
+	if err != nil {
+		return gjson.Result{}, err
+	}
+	entry := gjson.Get(fmt.Sprintf("%v", entries), "0")
+	if !entry.Exists() {
+		return gjson.Result{}, errors.New("the page has no first-input entry yet")
+	}
+	return entry, nil
+}
+
+// longTasks returns the durations of the long tasks recorded by the TrackLongTasks init script on the current page
+func (p *Playwright) longTasks() ([]float64, error) {
+	entries, err := p.Page.Evaluate("JSON.stringify(window.__xk6LongTasks ?? null)")
+	if err != nil {
+		return nil, err
+	}
+	result := gjson.Parse(fmt.Sprintf("%v", entries))
+	if !result.IsArray() {
+		return nil, errors.New("long tasks are not tracked on the page, call trackLongTasks before navigating")
+	}
+	var durations []float64
+	for _, duration := range result.Array() {
+		durations = append(durations, duration.Float())
+	}
+	return durations, nil
+}
+
+// performanceMetric returns the value of the devtools protocol performance metric of the current page
+func (p *Playwright) performanceMetric(name string) (int64, error) {
+	session, err := p.cdpSession()
+	if err != nil {
+		return 0, err
+	}
+	defer session.Detach()
+	if _, err := session.Send("Performance.enable", map[string]interface{}{}); err != nil {
+		return 0, err
+	}
+	result, err := session.Send("Performance.getMetrics", map[string]interface{}{})
+	if err != nil {
+		return 0, err
+	}
+	values, ok := result.(map[string]interface{})
+	if !ok {
+		return 0, fmt.Errorf("unexpected result of Performance.getMetrics: %v", result)
+	}
+	metrics, ok := values["metrics"].([]interface{})
+	if !ok {
+		return 0, fmt.Errorf("unexpected metrics in the result of Performance.getMetrics: %v", values["metrics"])
+	}
+	for _, metric := range metrics {
+		metric, ok := metric.(map[string]interface{})
+		if !ok || metric["name"] != name {
+			continue
+		}
+		value, ok := metric["value"].(float64)
+		if !ok {
+			return 0, fmt.Errorf("unexpected value of the %s performance metric: %v", name, metric["value"])
+		}
+		return int64(value), nil
+	}
+	return 0, fmt.Errorf("no %s performance metric", name)
+}
+
+// cdpSession opens a devtools protocol session to the current page, devtools sessions are only supported in chromium
+func (p *Playwright) cdpSession() (playwright.CDPSession, error) {
+	if p.engine != "chromium" {
+		return nil, fmt.Errorf("the devtools protocol is only supported in chromium, current browser is %q", p.engine)
+	}
+	return p.Page.Context().NewCDPSession(p.Page)
+}
+
+// elementCenter returns the center of the first element matching the selector in pixels
+func (p *Playwright) elementCenter(selector string) (float64, float64, error) {
+	element, err := p.Page.QuerySelector(selector)
+	if err != nil {
+		return 0, 0, err
+	}
+	if element == nil {
+		return 0, 0, fmt.Errorf("no element matches selector %q", selector)
+	}
+	box, err := boundingBox(element)
+	if err != nil {
+		return 0, 0, err
+	}
+	return box["x"] + box["width"]/2, box["y"] + box["height"]/2, nil
+}
+
+// vuID returns the id of the VU running the script, which is only known once the VU runs its default function
+func (p *Playwright) vuID() (uint64, error) {
+	if p.vu == nil || p.vu.State() == nil {
+		return 0, errors.New("the vu id is only available inside the default function")
+	}
+	return p.vu.State().VUID, nil
+}
+
+// eventSummary returns the serializable fields of an event payload
+func eventSummary(payload interface{}) interface{} {
+	switch payload := payload.(type) {
+	case nil:
+		return nil
+	case playwright.Page:
+		return map[string]interface{}{"url": payload.URL()}
+	case playwright.Download:
+		return map[string]interface{}{"url": payload.URL(), "suggestedFilename": payload.SuggestedFilename()}
+	case playwright.FileChooser:
+		return map[string]interface{}{"multiple": payload.IsMultiple()}
+	case playwright.Worker:
+		return map[string]interface{}{"url": payload.URL()}
+	case playwright.Request:
+		return map[string]interface{}{"url": payload.URL(), "method": payload.Method()}
+	case playwright.Response:
+		return map[string]interface{}{"url": payload.URL(), "status": payload.Status()}
+	case playwright.ConsoleMessage:
+		return map[string]interface{}{"type": payload.Type(), "text": payload.Text()}
+	case playwright.Dialog:
+		return map[string]interface{}{"type": payload.Type(), "message": payload.Message()}
+	case error:
+		return map[string]interface{}{"message": payload.Error()}
+	default:
+		return fmt.Sprintf("%v", payload)
+	}
+}
+
+// pageID returns the id of the page, registering it if it has none yet
+func (p *Playwright) pageID(page playwright.Page) int {
+	for id := range p.pages {
+		if p.pages[id] == page {
+			return id
+		}
+	}
+	p.pages = append(p.pages, page)
+	return len(p.pages) - 1
+}
+
+// waitTimeout returns the timeout of a wait in milliseconds, the given one if set, else the page default timeout or 30 seconds like playwright
+func (p *Playwright) waitTimeout(timeout *float64) float64 {
+	if timeout != nil {
+		return *timeout
+	}
+	if p.timeout != nil {
+		return *p.timeout
+	}
+	return 30000
+}
+
+// urlMatcher returns the matcher of a url pattern as every url option takes it: a Go regular expression between slashes, e.g. "/\\/orders\\/\\d+$/",
+// or otherwise a glob following the playwright rules (see globToRegexp)
+func urlMatcher(pattern string) (func(string) bool, error) {
+	var re *regexp.Regexp
+	var err error
+	if len(pattern) > 1 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		re, err = regexp.Compile(pattern[1 : len(pattern)-1])
+	} else {
+		re, err = globToRegexp(pattern)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return re.MatchString, nil
+}
+
+// globToRegexp converts a url glob to a regular expression following the playwright rules: "*" matches any characters but "/", "**" between slashes
+// or at an end of the pattern also matches "/", "?" matches a single character, "{a,b}" matches either a or b and a backslash escapes the next character
+func globToRegexp(glob string) (*regexp.Regexp, error) {
+	var pattern strings.Builder
+	pattern.WriteString("^")
+	inGroup := false
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case c == '\\' && i+1 < len(glob):
+			i++
+			pattern.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		case c == '*':
+			start := i
+			for i+1 < len(glob) && glob[i+1] == '*' {
+				i++
+			}
+			deep := i > start && (start == 0 || glob[start-1] == '/') && (i+1 == len(glob) || glob[i+1] == '/')
+			if deep {
+				pattern.WriteString("((?:[^/]*(?:/|$))*)")
+				// the slash after the wildcard is part of it
+				if i+1 < len(glob) {
+					i++
+				}
+			} else {
+				pattern.WriteString("([^/]*)")
+			}
+		case c == '?':
+			pattern.WriteString(".")
+		case c == '{':
+			inGroup = true
+			pattern.WriteString("(")
+		case c == '}':
+			inGroup = false
+			pattern.WriteString(")")
+		case c == ',' && inGroup:
+			pattern.WriteString("|")
+		default:
+			pattern.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	pattern.WriteString("$")
+	return regexp.Compile(pattern.String())
+}
+
+// sleep waits for the duration, returning early with the error of the VU context once it is done, e.g. when the test is stopped or aborted,
+// so waiting between actions cannot keep a stopped VU busy
+func (p *Playwright) sleep(duration time.Duration) error {
+	var ctx context.Context
+	if p.vu != nil {
+		ctx = p.vu.Context()
+	}
+	if ctx == nil {
+		time.Sleep(duration)
+		return nil
+	}
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// countLocator counts the elements matching the locator selector
+func (p *Playwright) countLocator(selector string) (int32, error) {
+	locator, err := p.Page.Locator(selector)
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with creating the locator")
+		return 0, err
+	}
+	count, err := locator.Count()
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error with counting the elements")
+		return 0, err
+	}
+	return int32(count), nil
+}
+
+// browserType returns the playwright browser type matching the given engine name, defaulting to chromium
+func browserType(pw *playwright.Playwright, engine string) (playwright.BrowserType, error) {
+	switch engine {
+	case "", "chromium":
+		return pw.Chromium, nil
+	case "firefox":
+		return pw.Firefox, nil
+	case "webkit":
+		return pw.WebKit, nil
+	default:
+		return nil, fmt.Errorf("unknown browser engine %q, expected one of chromium, firefox or webkit", engine)
+	}
+}
+
+// newContext creates a new browser context with a page and makes both of them the current ones
+func (p *Playwright) newContext(opts playwright.BrowserNewContextOptions) error {
+	context, page, err := p.createContext(opts)
+	if err != nil {
+		return err
+	}
+	p.BrowserContext = context
+	p.setPage(page)
+	return nil
+}
+
+// createContext creates a new browser context with the default options and the init scripts applied, and opens a page within it
+func (p *Playwright) createContext(opts playwright.BrowserNewContextOptions) (playwright.BrowserContext, playwright.Page, error) {
+	if p.Browser == nil {
+		return nil, nil, errors.New("no browser attached")
+	}
+	mergeOptions(&opts, p.contextDefaults)
+	if opts.RecordVideo != nil {
+		video := *opts.RecordVideo
+		video.Dir = outputPathPtr(video.Dir)
+		opts.RecordVideo = &video
+	}
+	context, err := p.Browser.NewContext(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range p.initScripts {
+		if err := context.AddInitScript(playwright.BrowserContextAddInitScriptOptions{Script: &p.initScripts[i]}); err != nil {
+			return nil, nil, err
+		}
+	}
+	page, err := context.NewPage()
+	if err != nil {
+		return nil, nil, err
+	}
+	return context, page, nil
+}
+
+// setPage makes the given page the current one and attaches the extension's event handlers to it
+func (p *Playwright) setPage(page playwright.Page) {
+	p.Page = page
+	p.watchPage(page)
+}
+
+// watchPage registers the dialog, console, page error and response handlers on the page and applies the default timeouts to it
+func (p *Playwright) watchPage(page playwright.Page) {
+	page.On("dialog", p.handleDialog)
+	page.On("console", p.handleConsole)
+	page.On("pageerror", p.handlePageError)
+	page.On("response", p.handleResponse)
+	page.On("requestfailed", p.handleRequestFailed)
+	page.On("request", func(playwright.Request) { p.trackRequest(page, 1) })
+	page.On("requestfinished", func(playwright.Request) { p.trackRequest(page, -1) })
+	page.On("requestfailed", func(playwright.Request) { p.trackRequest(page, -1) })
+	page.On("close", func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		delete(p.inflight, page)
+	})
+	if p.timeout != nil {
+		page.SetDefaultTimeout(*p.timeout)
+	}
+	if p.navTimeout != nil {
+		page.SetDefaultNavigationTimeout(*p.navTimeout)
+	}
+}
+
+// handleDialog accepts or dismisses a dialog according to OnDialog, dismissing it by default
+func (p *Playwright) handleDialog(dialog playwright.Dialog) {
+	p.mu.Lock()
+	action, promptText := p.dialogAction, p.dialogPrompt
+	p.mu.Unlock()
+	var err error
+	if action == "accept" {
+		err = dialog.Accept(promptText)
+	} else {
+		err = dialog.Dismiss()
+	}
+	p.logError(err, "xk6-playwright: error with handling the dialog")
+}
+
+// handleConsole buffers a console message if OnConsole was called
+func (p *Playwright) handleConsole(message playwright.ConsoleMessage) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.captureConsole {
+		p.consoleMessages = append(p.consoleMessages, "["+message.Type()+"] "+message.Text())
+	}
+}
+
+// handlePageError buffers an uncaught page exception if OnPageError was called
+func (p *Playwright) handlePageError(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.capturePageErr {
+		p.consoleMessages = append(p.consoleMessages, "[pageerror] "+err.Error())
+	}
+}
+
+// handleResponse records a response if OnResponse was called
+func (p *Playwright) handleResponse(response playwright.Response) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.captureResponse {
+		return
+	}
+	timeToFirstByte := float64(-1)
+	if timing := response.Request().Timing(); timing != nil && timing.ResponseStart >= 0 {
+		timeToFirstByte = timing.ResponseStart
+	}
+	p.responses = append(p.responses, ResponseRecord{
+		URL:             response.URL(),
+		Status:          response.Status(),
+		TimeToFirstByte: timeToFirstByte,
+	})
+}
+
+// handleRequestFailed records a failed request if OnRequestFailed was called
+func (p *Playwright) handleRequestFailed(request playwright.Request) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.captureFailed {
+		return
+	}
+	failure := ""
+	if request.Failure() != nil {
+		failure = request.Failure().ErrorText
+	}
+	p.failedRequests = append(p.failedRequests, map[string]string{
+		"url":     request.URL(),
+		"failure": failure,
+	})
+}
+
+// trackRequest updates the number of in-flight requests of the page by delta
+func (p *Playwright) trackRequest(page playwright.Page, delta int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.inflight == nil {
+		p.inflight = make(map[playwright.Page]int)
+	}
+	// requests started before the page was watched finish without having been counted
+	if p.inflight[page]+delta >= 0 {
+		p.inflight[page] += delta
+	}
+}
+
+// newPage creates a new page and returns it either with or without a context
+func (p *Playwright) newPage() (playwright.Page, error) {
+	if p.BrowserContext != nil {
+		return p.BrowserContext.NewPage()
+	}
+	if p.Browser != nil {
+		page, err := p.Browser.NewPage()
+		if err != nil {
+			return nil, err
+		}
+		for i := range p.initScripts {
+			if err := page.AddInitScript(playwright.PageAddInitScriptOptions{Script: &p.initScripts[i]}); err != nil {
+				return nil, err
+			}
+		}
+		return page, nil
 	}
 	return nil, errors.New("no browser or browser context attached")
 }
 
-// closeBrowser closes the browser and the browser context
+// browserContext returns the current browser context, either the one created explicitly or the browser's first context
+func (p *Playwright) browserContext() (playwright.BrowserContext, error) {
+	if p.BrowserContext != nil {
+		return p.BrowserContext, nil
+	}
+	if p.Browser != nil && len(p.Browser.Contexts()) > 0 {
+		return p.Browser.Contexts()[0], nil
+	}
+	return nil, errors.New("no browser or browser context attached")
+}
+
+// closeBrowser closes the browser contexts and then the browser, flushing the HAR file if one is being recorded. Every step is attempted even if
+// an earlier one fails, so a failing context does not leak the browser process, and the errors are returned joined together.
 func (p *Playwright) closeBrowser() error {
-	if p.Browser != nil {
-		return p.Browser.Close()
+	if p.Browser == nil && p.BrowserContext == nil {
+		return errors.New("no browser or browser context attached")
 	}
+	var errs []string
 	if p.BrowserContext != nil {
-		return p.BrowserContext.Close()
+		if err := p.stopTracing(p.BrowserContext); err != nil {
+			errs = append(errs, "cannot write the trace: "+err.Error())
+		}
+		if err := p.BrowserContext.Close(); err != nil {
+			errs = append(errs, "cannot close browser context: "+err.Error())
+		}
+	}
+	for name, named := range p.namedContexts {
+		if named.context != p.BrowserContext {
+			if err := named.context.Close(); err != nil {
+				errs = append(errs, fmt.Sprintf("cannot close browser context %q: %s", name, err))
+			}
+		}
+	}
+	if p.har != nil {
+		if err := p.har.write(); err != nil {
+			errs = append(errs, "cannot write the HAR file: "+err.Error())
+		}
+		p.har = nil
+	}
+	if p.Browser != nil {
+		if err := p.Browser.Close(); err != nil {
+			errs = append(errs, "cannot close browser: "+err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// stopTracing stops the trace started by LaunchDebug on the context and writes it, doing nothing if no trace is being recorded
+func (p *Playwright) stopTracing(context playwright.BrowserContext) error {
+	if p.tracePath == "" {
+		return nil
 	}
-	return errors.New("no browser or browser context attached")
+	path := p.tracePath
+	p.tracePath = ""
+	return context.Tracing().Stop(playwright.TracingStopOptions{Path: &path})
 }
 
 // cookies returns the cookies from the browser context or from browser persistent context
 func (p *Playwright) cookies() ([]*playwright.BrowserContextCookiesResult, error) {
-	if p.Browser != nil && len(p.Browser.Contexts()) > 0 {
-		return p.Browser.Contexts()[0].Cookies()
+	context, err := p.browserContext()
+	if err != nil {
+		return nil, err
 	}
-	if p.BrowserContext != nil {
-		return p.BrowserContext.Cookies()
+	return context.Cookies()
+}
+
+// setStorageItem sets an item in the given web storage (localStorage or sessionStorage) of the current page
+func (p *Playwright) setStorageItem(storage string, key string, value string) error {
+	_, err := p.Page.Evaluate("([storage, key, value]) => window[storage].setItem(key, value)", []string{storage, key, value})
+	return err
+}
+
+// getStorageItem gets an item from the given web storage (localStorage or sessionStorage) of the current page,
+// a missing key is returned as an empty string
+func (p *Playwright) getStorageItem(storage string, key string) (string, error) {
+	value, err := p.Page.Evaluate("([storage, key]) => window[storage].getItem(key)", []string{storage, key})
+	if err != nil {
+		return "", err
 	}
-	return nil, errors.New("no browser or browser context attached")
+	if value == nil {
+		return "", nil
+	}
+	return fmt.Sprintf("%v", value), nil
 }
 
-// ReportError reports an error if it is not nil
-func ReportError(err error, msg string) {
+// setInputFiles sets the files of the file input matching the selector, failing if the element is not an <input type=file>
+func (p *Playwright) setInputFiles(selector string, files []playwright.InputFile, opts playwright.FrameSetInputFilesOptions) error {
+	element, err := p.Page.QuerySelector(selector)
+	if err != nil {
+		return err
+	}
+	if element == nil {
+		return fmt.Errorf("no element matches selector %q", selector)
+	}
+	isFileInput, err := element.Evaluate("element => element instanceof HTMLInputElement && element.type === 'file'")
+	if err != nil {
+		return err
+	}
+	if isFileInput != true {
+		return fmt.Errorf("element matching selector %q is not an <input type=file>", selector)
+	}
+	return p.Page.SetInputFiles(selector, files, opts)
+}
+
+// errInvalidState is returned when an element state other than visible, hidden, enabled, disabled, editable or checked is requested
+var errInvalidState = errors.New("invalid state")
+
+// elementState returns whether the element is in the given state
+func elementState(element playwright.ElementHandle, state string) (bool, error) {
+	switch state {
+	case "visible":
+		return element.IsVisible()
+	case "hidden":
+		return element.IsHidden()
+	case "enabled":
+		return element.IsEnabled()
+	case "disabled":
+		return element.IsDisabled()
+	case "editable":
+		return element.IsEditable()
+	case "checked":
+		return element.IsChecked()
+	default:
+		return false, errInvalidState
+	}
+}
+
+// isState returns whether the element matching the selector is in the given state, failing if no element matches
+func (p *Playwright) isState(selector string, state string) (bool, error) {
+	element, err := p.Page.QuerySelector(selector)
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error querying selector")
+		return false, err
+	}
+	if element == nil {
+		err := fmt.Errorf("no element matches selector %q", selector)
+		p.reportError(err, "xk6-playwright: error checking "+state+" state")
+		return false, err
+	}
+	is, err := elementState(element, state)
+	if err != nil {
+		p.reportError(err, "xk6-playwright: error checking "+state+" state")
+		return false, err
+	}
+	return is, nil
+}
+
+// outputPath resolves a relative artifact path against the output directory set with SetOutputDir
+func outputPath(path string) string {
+	outputDirMu.RLock()
+	defer outputDirMu.RUnlock()
+	if outputDir == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(outputDir, path)
+}
+
+// outputPathPtr resolves an optional artifact path like outputPath, returning a new pointer so the options it was taken from are left as they are
+func outputPathPtr(path *string) *string {
+	if path == nil {
+		return nil
+	}
+	resolved := outputPath(*path)
+	return &resolved
+}
+
+// validateProxyServer checks that the proxy server is an http(s) or socks url with a host, or a host:port short form
+func validateProxyServer(server *string) error {
+	if server == nil || *server == "" {
+		return errors.New("proxy server is required")
+	}
+	raw := *server
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+	parsed, err := neturl.Parse(raw)
 	if err != nil {
-		fmt.Printf("%s: %s", msg, err)
+		return fmt.Errorf("invalid proxy server %q: %w", *server, err)
 	}
+	switch parsed.Scheme {
+	case "http", "https", "socks4", "socks5":
+	default:
+		return fmt.Errorf("invalid proxy server %q: unsupported scheme %q, expected http, https, socks4 or socks5", *server, parsed.Scheme)
+	}
+	if parsed.Hostname() == "" {
+		return fmt.Errorf("invalid proxy server %q: missing host", *server)
+	}
+	return nil
+}
+
+// proxyError makes proxy connection and authentication failures explicit when the browser was launched with a proxy
+func (p *Playwright) proxyError(err error) error {
+	if p.proxy == nil {
+		return err
+	}
+	message := err.Error()
+	switch {
+	case strings.Contains(message, "ERR_PROXY_AUTH") || strings.Contains(message, "407"):
+		return fmt.Errorf("proxy %s rejected the credentials: %w", *p.proxy, err)
+	case strings.Contains(message, "ERR_PROXY_CONNECTION_FAILED") || strings.Contains(message, "ERR_TUNNEL_CONNECTION_FAILED"):
+		return fmt.Errorf("cannot connect through proxy %s: %w", *p.proxy, err)
+	}
+	return err
+}
+
+// writeFile writes data to the given path, creating any missing parent directories
+func writeFile(path string, data []byte, perm fs.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, perm)
+}
+
+// mergeOptions sets the fields of the options dst points to that are not set yet to the field of the same name of defaults,
+// converting between the equivalent option structs of different playwright functions, such as their viewports, through JSON
+func mergeOptions(dst interface{}, defaults interface{}) {
+	target := reflect.ValueOf(dst).Elem()
+	source := reflect.ValueOf(defaults)
+	for i := 0; i < source.NumField(); i++ {
+		value := source.Field(i)
+		field := target.FieldByName(source.Type().Field(i).Name)
+		if value.IsZero() || !field.IsValid() || !field.IsZero() {
+			continue
+		}
+		if value.Type().AssignableTo(field.Type()) {
+			field.Set(value)
+			continue
+		}
+		data, err := json.Marshal(value.Interface())
+		if err != nil || field.Kind() != reflect.Ptr {
+			continue
+		}
+		converted := reflect.New(field.Type().Elem())
+		if json.Unmarshal(data, converted.Interface()) == nil {
+			field.Set(converted)
+		}
+	}
+}
+
+// reportError reports an error like ReportError and takes a screenshot of the current page if SetScreenshotOnError was called,
+// handles built outside of a Playwright have no owner and only report it
+func (p *Playwright) reportError(err error, msg string) {
+	p.logError(err, msg)
+	// the screenshot failing must not trigger another screenshot
+	if err == nil || p == nil || p.errorShotDir == "" || p.Page == nil || p.capturingError {
+		return
+	}
+	p.capturingError = true
+	defer func() { p.capturingError = false }()
+	path := outputPath(filepath.Join(p.errorShotDir, fmt.Sprintf("error-%d.png", time.Now().UnixNano()/int64(time.Millisecond))))
+	screenshot, shotErr := p.Page.Screenshot()
+	if shotErr == nil {
+		shotErr = writeFile(path, screenshot, 0644)
+	}
+	p.logError(shotErr, "xk6-playwright: error with taking the screenshot of the failure")
+}
+
+// logError reports an error if it is not nil through the logger of the VU at error level, without the screenshot of reportError,
+// for the event and route handlers that run outside of the iteration
+func (p *Playwright) logError(err error, msg string) {
+	if err == nil {
+		return
+	}
+	p.logger().WithError(err).Error(msg)
+}
+
+// ReportError reports an error if it is not nil through the logrus standard logger at error level, the methods of Playwright report
+// through the logger of their VU instead
+func ReportError(err error, msg string) {
+	if err == nil {
+		return
+	}
+	logrus.StandardLogger().WithError(err).Error(msg)
+}
+
+// reportWarning reports a warning through the logger of the VU
+func (p *Playwright) reportWarning(msg string) {
+	p.logger().Warn(msg)
 }
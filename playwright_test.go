@@ -1,10 +1,14 @@
 package playwright
 
 import (
+	"errors"
 	"fmt"
+	"io/ioutil"
 	"math/rand"
+	"path/filepath"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/playwright-community/playwright-go"
 )
@@ -14,6 +18,24 @@ var tests = []func(t *testing.T){
 	TestPlaywright2,
 	TestCookies,
 	TestPersistentContext,
+	TestStorage,
+	TestConsoleMessages,
+	TestRetry,
+	TestNetworkErrors,
+	TestRetryableErrors,
+	TestRoleSelector,
+	TestTextSelector,
+	TestCSSString,
+	TestGlobToRegexp,
+	TestURLMatcher,
+	TestValidateProxyServer,
+	TestMergeOptions,
+	TestBuildAXTree,
+	TestHarDuration,
+	TestPoll,
+	TestEventSummary,
+	TestReadFixtures,
+	TestRouterThrottle,
 }
 
 func TestPlaywright(t *testing.T) {
@@ -49,8 +71,8 @@ func TestPlaywright2(t *testing.T) {
 	pw.Type("input[name='q']", "how to measure real user metrics with the xk6-playwright extension for k6?", opts4)
 	fp := pw.FirstPaint()
 	fcp := pw.FirstContentfulPaint()
-	ttmi := pw.TimeToMinimallyInteractive()
-	fid := pw.FirstInputDelay()
+	ttmi, _ := pw.TimeToMinimallyInteractive()
+	fid, _ := pw.FirstInputDelay()
 	fmt.Printf("First Paint: %v \n", fp)
 	fmt.Printf("First Contentful Paint: %v \n", fcp)
 	fmt.Printf("Time to Minimally Interactive: %v \n", ttmi)
@@ -101,6 +123,411 @@ func TestCookies(t *testing.T) {
 	pw.Kill()
 }
 
+func TestStorage(t *testing.T) {
+	var pw Playwright
+	headless := true
+	opts := playwright.BrowserTypeLaunchOptions{
+		Headless: &headless,
+	}
+	var opts2 playwright.PageGotoOptions
+
+	pw.Launch(opts)
+	pw.NewPage()
+	pw.Goto("https://www.github.com", opts2)
+	pw.SetLocalStorage("xk6-playwright", "local")
+	pw.SetSessionStorage("xk6-playwright", "session")
+	local, err := pw.GetLocalStorage("xk6-playwright")
+	if err != nil || local != "local" {
+		t.Errorf("expected the local storage item to be %q, got %q, %v", "local", local, err)
+	}
+	session, err := pw.GetSessionStorage("xk6-playwright")
+	if err != nil || session != "session" {
+		t.Errorf("expected the session storage item to be %q, got %q, %v", "session", session, err)
+	}
+	pw.Kill()
+}
+
+func TestConsoleMessages(t *testing.T) {
+	var pw Playwright
+	headless := true
+	opts := playwright.BrowserTypeLaunchOptions{
+		Headless: &headless,
+	}
+	var opts2 playwright.PageGotoOptions
+
+	pw.Launch(opts)
+	pw.NewPage()
+	pw.OnConsole()
+	pw.Goto("https://www.github.com", opts2)
+	pw.Evaluate("console.error('xk6-playwright console error')", nil)
+	pw.Sleep(500)
+	messages := pw.ConsoleMessages()
+	found := false
+	for _, message := range messages {
+		if message == "[error] xk6-playwright console error" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("console error not captured, got %v", messages)
+	}
+	pw.Kill()
+}
+
+func TestRetry(t *testing.T) {
+	var pw Playwright
+	calls := 0
+	transient := func() (interface{}, error) {
+		calls++
+		if calls < 3 {
+			return nil, errors.New("Element is not attached to the DOM")
+		}
+		return calls, nil
+	}
+	if value, err := pw.Retry(transient, 3, 1); err != nil || value != 3 {
+		t.Errorf("expected transient action to succeed on the third attempt, got %v, %v", value, err)
+	}
+
+	calls = 0
+	fatal := func() (interface{}, error) {
+		calls++
+		return nil, errors.New("Timeout 30000ms exceeded")
+	}
+	if _, err := pw.Retry(fatal, 3, 1); err == nil || calls != 1 {
+		t.Errorf("expected fatal action to fail without retrying, got %d calls, %v", calls, err)
+	}
+}
+
+func TestNetworkErrors(t *testing.T) {
+	cases := []struct {
+		message string
+		network bool
+	}{
+		{"net::ERR_CONNECTION_REFUSED at http://localhost:3000/", true},
+		{"net::ERR_NAME_NOT_RESOLVED at http://backend/", true},
+		{"NS_ERROR_CONNECTION_REFUSED", true},
+		{"Could not connect to the server.", true},
+		{"Timeout 30000ms exceeded", false},
+		{"Navigation interrupted by another one", false},
+	}
+	for _, c := range cases {
+		if got := isNetworkError(errors.New(c.message)); got != c.network {
+			t.Errorf("isNetworkError(%q) = %v, expected %v", c.message, got, c.network)
+		}
+	}
+}
+
+func TestRetryableErrors(t *testing.T) {
+	cases := []struct {
+		message   string
+		retryable bool
+	}{
+		{"Element is not attached to the DOM", true},
+		{"Execution context was destroyed, most likely because of a navigation", true},
+		{"net::ERR_CONNECTION_RESET", true},
+		{"Timeout 30000ms exceeded", false},
+		{"net::ERR_CONNECTION_REFUSED", false},
+	}
+	for _, c := range cases {
+		if got := isRetryable(errors.New(c.message)); got != c.retryable {
+			t.Errorf("isRetryable(%q) = %v, expected %v", c.message, got, c.retryable)
+		}
+	}
+}
+
+func TestRoleSelector(t *testing.T) {
+	name := "Submit"
+	exact := true
+	cases := []struct {
+		role     string
+		opts     GetByRoleOptions
+		expected string
+	}{
+		{"checkbox", GetByRoleOptions{}, `[role="checkbox"], input[type=checkbox]`},
+		{"alert", GetByRoleOptions{}, `[role="alert"]`},
+		{"link", GetByRoleOptions{Name: &name}, `[role="link"]:has-text("Submit"), a[href]:has-text("Submit"), area[href]:has-text("Submit")`},
+		{"combobox", GetByRoleOptions{Name: &name, Exact: &exact}, `[role="combobox"]:text-is("Submit"), select:text-is("Submit")`},
+	}
+	for _, c := range cases {
+		if got := roleSelector(c.role, c.opts); got != c.expected {
+			t.Errorf("roleSelector(%q) = %q, expected %q", c.role, got, c.expected)
+		}
+	}
+	for role, selectors := range implicitRoles {
+		if len(selectors) == 0 {
+			t.Errorf("implicit role %q has no selectors", role)
+		}
+	}
+}
+
+func TestTextSelector(t *testing.T) {
+	cases := []struct {
+		text     string
+		exact    bool
+		expected string
+	}{
+		{"Sign in", false, `text=/Sign\s+in/i`},
+		{`"quoted"`, false, `text=/\x22quoted\x22/i`},
+		{"/api/", false, `text=/\x2fapi\x2f/i`},
+		{"a >> b", false, `text=/a\s+\x3e\x3e\s+b/i`},
+		{"Sign in", true, `text="Sign in"`},
+		{"Say \"hi\"\n now", true, `text="Say \"hi\" now"`},
+	}
+	for _, c := range cases {
+		if got := textSelector(c.text, c.exact); got != c.expected {
+			t.Errorf("textSelector(%q, %v) = %q, expected %q", c.text, c.exact, got, c.expected)
+		}
+	}
+}
+
+func TestCSSString(t *testing.T) {
+	cases := []struct {
+		value    string
+		expected string
+	}{
+		{"submit", `"submit"`},
+		{`say "hi"`, `"say \"hi\""`},
+		{`C:\temp`, `"C:\\temp"`},
+		{"line\nbreak\t", `"line\a break\9 "`},
+		{"café", `"café"`},
+	}
+	for _, c := range cases {
+		if got := cssString(c.value); got != c.expected {
+			t.Errorf("cssString(%q) = %s, expected %s", c.value, got, c.expected)
+		}
+	}
+}
+
+func TestGlobToRegexp(t *testing.T) {
+	cases := []struct {
+		glob    string
+		url     string
+		matches bool
+	}{
+		{"**/api/users", "https://example.com/api/users", true},
+		{"**/api/*", "https://example.com/api/users", true},
+		{"**/api/*", "https://example.com/api/users/42", false},
+		{"**/api/**", "https://example.com/api/users/42", true},
+		{"https://example.com/*.js", "https://example.com/app.js", true},
+		{"https://example.com/*.js", "https://example.com/static/app.js", false},
+		{"**/*.{png,jpg}", "https://example.com/logo.jpg", true},
+		{"**/*.{png,jpg}", "https://example.com/logo.gif", false},
+		{"**/search?q=*", "https://example.com/search?q=k6", true},
+		{"**/v1.0/*", "https://example.com/v1x0/users", false},
+	}
+	for _, c := range cases {
+		re, err := globToRegexp(c.glob)
+		if err != nil {
+			t.Errorf("globToRegexp(%q) failed: %v", c.glob, err)
+			continue
+		}
+		if got := re.MatchString(c.url); got != c.matches {
+			t.Errorf("glob %q matching %q = %v, expected %v", c.glob, c.url, got, c.matches)
+		}
+	}
+}
+
+func TestURLMatcher(t *testing.T) {
+	cases := []struct {
+		pattern string
+		url     string
+		matches bool
+	}{
+		{`/\/orders\/\d+$/`, "https://example.com/orders/42", true},
+		{`/\/orders\/\d+$/`, "https://example.com/orders/42/items", false},
+		{"/checkout/", "https://example.com/checkout/done", true},
+		{`/^https://example\.com//`, "https://example.org/", false},
+		{"**/orders/*", "https://example.com/orders/42", true},
+		{"/", "/", true},
+	}
+	for _, c := range cases {
+		matches, err := urlMatcher(c.pattern)
+		if err != nil {
+			t.Errorf("urlMatcher(%q) failed: %v", c.pattern, err)
+			continue
+		}
+		if got := matches(c.url); got != c.matches {
+			t.Errorf("pattern %q matching %q = %v, expected %v", c.pattern, c.url, got, c.matches)
+		}
+	}
+	if _, err := urlMatcher("/(unclosed/"); err == nil {
+		t.Errorf("expected an invalid regular expression to fail")
+	}
+}
+
+func TestValidateProxyServer(t *testing.T) {
+	empty := ""
+	cases := []struct {
+		server *string
+		valid  bool
+	}{
+		{nil, false},
+		{&empty, false},
+		{playwright.String("http://proxy.example.com:3128"), true},
+		{playwright.String("socks5://127.0.0.1:1080"), true},
+		{playwright.String("proxy.example.com:3128"), true},
+		{playwright.String("ftp://proxy.example.com"), false},
+		{playwright.String("http://"), false},
+	}
+	for _, c := range cases {
+		if err := validateProxyServer(c.server); (err == nil) != c.valid {
+			t.Errorf("validateProxyServer(%v) = %v, expected valid %v", c.server, err, c.valid)
+		}
+	}
+}
+
+func TestMergeOptions(t *testing.T) {
+	defaults := playwright.BrowserNewContextOptions{
+		Locale:    playwright.String("de-DE"),
+		UserAgent: playwright.String("default-agent"),
+		Viewport:  &playwright.BrowserNewContextOptionsViewport{Width: playwright.Int(800), Height: playwright.Int(600)},
+	}
+	opts := playwright.BrowserNewContextOptions{UserAgent: playwright.String("explicit-agent")}
+	mergeOptions(&opts, defaults)
+	if opts.Locale == nil || *opts.Locale != "de-DE" {
+		t.Errorf("expected the default locale to be merged, got %v", opts.Locale)
+	}
+	if *opts.UserAgent != "explicit-agent" {
+		t.Errorf("expected the explicit user agent to be kept, got %q", *opts.UserAgent)
+	}
+
+	persistent := playwright.BrowserTypeLaunchPersistentContextOptions{}
+	mergeOptions(&persistent, defaults)
+	if persistent.Viewport == nil || persistent.Viewport.Width == nil || *persistent.Viewport.Width != 800 {
+		t.Errorf("expected the default viewport to be converted, got %v", persistent.Viewport)
+	}
+}
+
+func TestBuildAXTree(t *testing.T) {
+	nodes := []axNode{
+		{NodeID: "1", Role: &axValue{Value: "RootWebArea"}, Name: &axValue{Value: "Home"}, ChildIDs: []string{"2"}},
+		{NodeID: "2", ParentID: "1", Role: &axValue{Value: "generic"}, ChildIDs: []string{"3", "4"}},
+		{NodeID: "3", ParentID: "2", Role: &axValue{Value: "button"}, Name: &axValue{Value: "Save"}},
+		{NodeID: "4", ParentID: "2", Ignored: true, Role: &axValue{Value: "none"}},
+	}
+	byID := make(map[string]*axNode, len(nodes))
+	for i := range nodes {
+		byID[nodes[i].NodeID] = &nodes[i]
+	}
+	cases := []struct {
+		interestingOnly bool
+		childRole       string
+	}{
+		{true, "button"},
+		{false, "generic"},
+	}
+	for _, c := range cases {
+		tree := buildAXTree(&nodes[0], byID, c.interestingOnly)
+		if len(tree) != 1 || tree[0]["name"] != "Home" {
+			t.Fatalf("expected a single root named Home, got %v", tree)
+		}
+		children, _ := tree[0]["children"].([]map[string]interface{})
+		if len(children) != 1 || children[0]["role"] != c.childRole {
+			t.Errorf("interestingOnly %v: expected a single %s child, got %v", c.interestingOnly, c.childRole, children)
+		}
+	}
+}
+
+func TestHarDuration(t *testing.T) {
+	cases := []struct {
+		start, end, expected float64
+	}{
+		{10, 25, 15},
+		{0, 0, 0},
+		{-1, 25, -1},
+		{10, -1, -1},
+	}
+	for _, c := range cases {
+		if got := harDuration(c.start, c.end); got != c.expected {
+			t.Errorf("harDuration(%v, %v) = %v, expected %v", c.start, c.end, got, c.expected)
+		}
+	}
+}
+
+func TestPoll(t *testing.T) {
+	var pw Playwright
+	calls := 0
+	if err := pw.poll(1000, func(float64) (bool, error) { calls++; return calls == 2, nil }); err != nil || calls != 2 {
+		t.Errorf("expected poll to succeed on the second check, got %d checks, %v", calls, err)
+	}
+	cause := errors.New("not yet")
+	if err := pw.poll(0, func(float64) (bool, error) { return false, cause }); err != cause {
+		t.Errorf("expected poll to return the last error, got %v", err)
+	}
+	if err := pw.poll(0, func(float64) (bool, error) { return false, nil }); err == nil {
+		t.Errorf("expected poll to time out")
+	}
+	var remaining []float64
+	pw.poll(250.5, func(left float64) (bool, error) { remaining = append(remaining, left); return len(remaining) == 2, nil })
+	if len(remaining) != 2 || remaining[0] > 250.5 || remaining[0] < 200 || remaining[1] >= remaining[0] {
+		t.Errorf("expected poll to pass the shrinking time left to the checks, got %v", remaining)
+	}
+	if err := pw.poll(0.5, func(left float64) (bool, error) { return left >= 1, nil }); err != nil {
+		t.Errorf("expected poll to never pass less than 1ms to the checks, got %v", err)
+	}
+}
+
+func TestEventSummary(t *testing.T) {
+	cases := []struct {
+		payload  interface{}
+		expected string
+	}{
+		{nil, "<nil>"},
+		{errors.New("page crashed"), "map[message:page crashed]"},
+		{42, "42"},
+	}
+	for _, c := range cases {
+		if got := fmt.Sprint(eventSummary(c.payload)); got != c.expected {
+			t.Errorf("eventSummary(%v) = %s, expected %s", c.payload, got, c.expected)
+		}
+	}
+}
+
+func TestReadFixtures(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"users.json": `{"urlPattern": "**/api/users", "status": 201, "bodyFile": "body.json"}`,
+		"body.json":  `{"urlPattern": "**/api/decoy"}`,
+		"list.json":  `[1, 2, 3]`,
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rules, err := readFixtures(dir)
+	if err != nil {
+		t.Fatalf("readFixtures failed: %v", err)
+	}
+	if len(rules) != 1 {
+		t.Fatalf("expected the body file and the other JSON files to be skipped, got %d fixtures", len(rules))
+	}
+	fixture := rules[0].fixture
+	if !rules[0].matches("https://example.com/api/users") || *fixture.Status != 201 || string(fixture.body) != files["body.json"] {
+		t.Errorf("unexpected fixture %+v", fixture)
+	}
+	if fixture.Headers["content-type"] != "application/json" {
+		t.Errorf("expected the content type of the body file, got %q", fixture.Headers["content-type"])
+	}
+}
+
+func TestRouterThrottle(t *testing.T) {
+	var r router
+	matches, _ := urlMatcher("**/api/**")
+	r.throttle("**/api/**", matches, time.Second)
+	r.throttle("**/api/**", matches, 2*time.Second)
+	if !r.matches("https://example.com/api/users") || r.delay("https://example.com/api/users") != 2*time.Second {
+		t.Errorf("expected the second throttle to replace the first, got %v", r.throttles)
+	}
+	if r.matches("https://example.com/index.html") {
+		t.Errorf("expected the router to leave the requests without a rule alone")
+	}
+	r.throttle("**/api/**", matches, 0)
+	if len(r.throttles) != 0 || r.matches("https://example.com/api/users") {
+		t.Errorf("expected a delay of 0 to remove the throttle, got %v", r.throttles)
+	}
+}
+
 func TestAll(t *testing.T) {
 	for i, test := range tests {
 		t.Run(strconv.Itoa(i), test)
@@ -0,0 +1,118 @@
+package playwright
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// retryableErrors are fragments of the playwright errors caused by a transient page state, an action failing with any other error is not retried
+var retryableErrors = []string{
+	"Element is not attached to the DOM",
+	"element is detached",
+	"Execution context was destroyed",
+	"Cannot find context with specified id",
+	"interrupted by another navigation",
+	"Navigation interrupted",
+	"frame was detached",
+	"net::ERR_NETWORK_CHANGED",
+	"net::ERR_CONNECTION_RESET",
+}
+
+// networkErrors are fragments of the navigation errors raised when no http response was received, net::ERR_* in chromium and their firefox and webkit counterparts
+var networkErrors = []string{
+	"net::ERR_",
+	"NS_ERROR_",
+	"Could not connect",
+}
+
+// Retry runs fn up to attempts times while it fails with a transient playwright error (detached element, interrupted navigation, ...),
+// waiting backoffMs milliseconds before the first retry and doubling the wait after each one. Fatal errors, such as a selector that
+// never appears, are returned right away.
+func (p *Playwright) Retry(fn func() (interface{}, error), attempts int, backoffMs float64) (interface{}, error) {
+	if attempts < 1 {
+		attempts = 1
+	}
+	backoff := time.Duration(backoffMs * float64(time.Millisecond))
+	var err error
+	attempt := 1
+	for ; ; attempt++ {
+		var value interface{}
+		value, err = fn()
+		if err == nil {
+			return value, nil
+		}
+		if attempt == attempts || !isRetryable(err) {
+			break
+		}
+		if sleepErr := p.sleep(backoff); sleepErr != nil {
+			err = sleepErr
+			break
+		}
+		backoff *= 2
+	}
+	err = fmt.Errorf("action failed after %d attempt(s): %w", attempt, err)
+	p.reportError(err, "xk6-playwright: error with retrying the action")
+	return nil, err
+}
+
+// isRetryable returns whether the error is caused by a transient page state
+func isRetryable(err error) bool {
+	for _, fragment := range retryableErrors {
+		if strings.Contains(err.Error(), fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// GotoWithRetry navigates like GotoWithResponse and retries up to attempts times when the navigation fails without an http response, e.g. with
+// net::ERR_CONNECTION_REFUSED while a cold backend ramps up, waiting backoffMs milliseconds before the first retry and doubling the wait after each one.
+// An http response ends the navigation whatever its status, so 4xx and 5xx responses are returned right away for the script to check.
+func (p *Playwright) GotoWithRetry(url string, opts playwright.PageGotoOptions, attempts int, backoffMs float64) (map[string]interface{}, error) {
+	if attempts < 1 {
+		attempts = 1
+	}
+	backoff := time.Duration(backoffMs * float64(time.Millisecond))
+	var err error
+	attempt := 1
+	for ; ; attempt++ {
+		var response playwright.Response
+		response, err = p.Page.Goto(url, opts)
+		if err == nil {
+			if response == nil {
+				return map[string]interface{}{}, nil
+			}
+			return map[string]interface{}{
+				"status":   response.Status(),
+				"ok":       response.Ok(),
+				"url":      response.URL(),
+				"headers":  response.Headers(),
+				"attempts": attempt,
+			}, nil
+		}
+		if attempt == attempts || !isNetworkError(err) {
+			break
+		}
+		if sleepErr := p.sleep(backoff); sleepErr != nil {
+			err = sleepErr
+			break
+		}
+		backoff *= 2
+	}
+	err = fmt.Errorf("navigation failed after %d attempt(s): %w", attempt, p.proxyError(err))
+	p.reportError(err, "xk6-playwright: error when goto url")
+	return nil, err
+}
+
+// isNetworkError returns whether the navigation error was raised before any http response was received
+func isNetworkError(err error) bool {
+	for _, fragment := range networkErrors {
+		if strings.Contains(err.Error(), fragment) {
+			return true
+		}
+	}
+	return false
+}
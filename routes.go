@@ -0,0 +1,121 @@
+package playwright
+
+import (
+	"sync"
+	"time"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// router is the single route registered on a browser context, it dispatches every request to the rules of BlockResourceTypes, ThrottleRequests
+// and MockFromDir, so the rules compose instead of hiding each other and removing one never unroutes a route registered by someone else
+type router struct {
+	mu        sync.Mutex
+	blocked   map[string]bool
+	throttles []throttleRule
+	mocks     []mockRule
+}
+
+// throttleRule delays the requests whose url matches before they go on
+type throttleRule struct {
+	pattern string
+	matches func(string) bool
+	delay   time.Duration
+}
+
+// mockRule fulfills the requests whose url matches with the fixture
+type mockRule struct {
+	matches func(string) bool
+	fixture mockFixture
+}
+
+// matches reports whether a rule applies to the url, the others are left to the routes registered after the router
+func (r *router) matches(url string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.blocked) > 0 || r.delay(url) > 0 || r.mock(url) != nil
+}
+
+// delay returns the delay of the first throttle whose pattern matches the url, the caller holds mu
+func (r *router) delay(url string) time.Duration {
+	for _, throttle := range r.throttles {
+		if throttle.matches(url) {
+			return throttle.delay
+		}
+	}
+	return 0
+}
+
+// throttle sets the delay of the requests matching the pattern, replacing the delay set before for the same pattern, a delay of 0 removes it
+func (r *router) throttle(pattern string, matches func(string) bool, delay time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	throttles := r.throttles[:0:0]
+	for _, throttle := range r.throttles {
+		if throttle.pattern != pattern {
+			throttles = append(throttles, throttle)
+		}
+	}
+	if delay > 0 {
+		throttles = append(throttles, throttleRule{pattern: pattern, matches: matches, delay: delay})
+	}
+	r.throttles = throttles
+}
+
+// mock returns the first fixture whose pattern matches the url, the caller holds mu
+func (r *router) mock(url string) *mockFixture {
+	for i := range r.mocks {
+		if r.mocks[i].matches(url) {
+			return &r.mocks[i].fixture
+		}
+	}
+	return nil
+}
+
+// contextRouter returns the router of the current browser context, registering it on the first call
+func (p *Playwright) contextRouter() (*router, error) {
+	context, err := p.browserContext()
+	if err != nil {
+		return nil, err
+	}
+	if r, ok := p.routers[context]; ok {
+		return r, nil
+	}
+	r := &router{}
+	if err := context.Route(r.matches, func(route playwright.Route, request playwright.Request) {
+		p.dispatch(r, route, request)
+	}); err != nil {
+		return nil, err
+	}
+	if p.routers == nil {
+		p.routers = make(map[playwright.BrowserContext]*router)
+	}
+	p.routers[context] = r
+	return r, nil
+}
+
+// dispatch aborts the request if its resource type is blocked, then delays it if it is throttled and fulfills it if a mock matches
+// or lets it continue otherwise, so a throttled mock is served late like the response it stands for
+func (p *Playwright) dispatch(r *router, route playwright.Route, request playwright.Request) {
+	r.mu.Lock()
+	blocked := r.blocked[request.ResourceType()]
+	delay := r.delay(request.URL())
+	mock := r.mock(request.URL())
+	r.mu.Unlock()
+	if !blocked && delay > 0 && p.sleep(delay) != nil {
+		// the test is stopping, the request is aborted with its context
+		return
+	}
+	var err error
+	switch {
+	case blocked:
+		err = route.Abort("blockedbyclient")
+	case mock != nil:
+		err = route.Fulfill(playwright.RouteFulfillOptions{Status: mock.Status, Headers: mock.Headers, Body: mock.body})
+	default:
+		err = route.Continue()
+	}
+	if err != nil {
+		p.logError(err, "xk6-playwright: error with routing the request")
+	}
+}